@@ -48,10 +48,14 @@ func getRequestSignatureHash(method, endpoint string, body interface{},
 		bodyString = string(bodyBytes)
 	}
 
-	// Set the signature string
+	return getSignatureHash(method, endpoint, timestamp, bodyString), nil
+}
+
+// getSignatureHash will return the signature hash for the raw body string
+func getSignatureHash(method, endpoint, timestamp, bodyString string) []byte {
 	signatureString := fmt.Sprintf("%s\n%s\n%s\n%s", method, endpoint, timestamp, bodyString)
 	hash := sha256.Sum256([]byte(signatureString))
-	return hash[:], nil
+	return hash[:]
 }
 
 // getSignedRequest returns the request with signature
@@ -60,6 +64,11 @@ func getRequestSignatureHash(method, endpoint string, body interface{},
 func (c *Client) getSignedRequest(method, endpoint, authToken string,
 	body interface{}, timestamp string) (*signedRequest, error) {
 
+	// Unknown environment (see NewClient)
+	if c.environmentErr != nil {
+		return nil, c.environmentErr
+	}
+
 	// Decode token
 	tokenBytes, err := hex.DecodeString(authToken)
 	if err != nil {
@@ -78,8 +87,9 @@ func (c *Client) getSignedRequest(method, endpoint, authToken string,
 	}
 
 	// Return the signed request
-	return &signedRequest{
-		Body: body,
+	signed := &signedRequest{
+		Body:     body,
+		Endpoint: endpoint,
 		Headers: oAuthHeaders{
 			OauthPublicKey: hex.EncodeToString(publicKey.SerialiseCompressed()),
 			OauthSignature: hex.EncodeToString(requestSignature),
@@ -88,5 +98,15 @@ func (c *Client) getSignedRequest(method, endpoint, authToken string,
 		JSON:   true,
 		Method: method,
 		URI:    c.Environment.APIURL + endpoint,
-	}, nil
+	}
+	signed.resign = func(newTimestamp string) error {
+		signature, signErr := getRequestSignature(method, endpoint, body, newTimestamp, privateKey)
+		if signErr != nil {
+			return signErr
+		}
+		signed.Headers.OauthSignature = hex.EncodeToString(signature)
+		signed.Headers.OauthTimestamp = newTimestamp
+		return nil
+	}
+	return signed, nil
 }
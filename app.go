@@ -0,0 +1,128 @@
+package handcash
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// App-level headers sent by a client created for an App
+const (
+	headerAppID     = "app-id"
+	headerAppSecret = "app-secret"
+)
+
+// App is a HandCash app (from the HandCash developer dashboard)
+type App struct {
+	AppID       string `json:"app_id"`
+	AppSecret   string `json:"-"`           // Never serialized
+	Environment string `json:"environment"` // IE: EnvironmentProduction
+}
+
+// validate will check the app has credentials and a known environment
+func (a *App) validate() error {
+	if a == nil {
+		return fmt.Errorf("missing app")
+	} else if len(a.AppID) == 0 {
+		return fmt.Errorf("missing app id")
+	} else if len(a.AppSecret) == 0 {
+		return fmt.Errorf("missing app secret for app: %s", a.AppID)
+	} else if _, err := GetEnvironment(a.Environment); err != nil {
+		return fmt.Errorf("invalid app %s: %w", a.AppID, err)
+	}
+	return nil
+}
+
+// NewAppClient will return a new client for the app
+//
+// The client sends the app-level headers (app-id and app-secret) with every request
+func NewAppClient(app *App, options *ClientOptions, customHTTPClient *http.Client) (*Client, error) {
+	if err := app.validate(); err != nil {
+		return nil, err
+	}
+	c := NewClient(options, customHTTPClient, app.Environment)
+	appCopy := *app
+	c.app = &appCopy
+	return c, nil
+}
+
+// App will return the app of the client (nil if the client was not created for an app)
+func (c *Client) App() *App {
+	if c.app == nil {
+		return nil
+	}
+	app := *c.app
+	return &app
+}
+
+// GetAppRedirectionURL will return the authorization URL for the app of the client
+func (c *Client) GetAppRedirectionURL(params map[string]string) (string, error) {
+	if c.app == nil {
+		return "", fmt.Errorf("client was not created for an app")
+	}
+	return c.GetRedirectionURL(c.app.AppID, params)
+}
+
+// setAppHeaders will add the app-level headers (if the client has an app)
+func (c *Client) setAppHeaders(req *http.Request) {
+	if c.app != nil {
+		req.Header.Set(headerAppID, c.app.AppID)
+		req.Header.Set(headerAppSecret, c.app.AppSecret)
+	}
+}
+
+// AppRegistry holds a configured client per app (safe for concurrent use)
+type AppRegistry struct {
+	clients          map[string]*Client // Keyed by app ID
+	customHTTPClient *http.Client
+	mu               sync.RWMutex
+	options          *ClientOptions
+}
+
+// NewAppRegistry will return a new registry (the options and HTTP client are used for every app)
+func NewAppRegistry(options *ClientOptions, customHTTPClient *http.Client) *AppRegistry {
+	return &AppRegistry{
+		clients:          make(map[string]*Client),
+		customHTTPClient: customHTTPClient,
+		options:          options,
+	}
+}
+
+// Register will add the app (replacing any app with the same ID) and return its client
+func (r *AppRegistry) Register(app *App) (*Client, error) {
+	client, err := NewAppClient(app, r.options, r.customHTTPClient)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[app.AppID] = client
+	return client, nil
+}
+
+// Client will return the client for the app
+func (r *AppRegistry) Client(appID string) (*Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.clients[appID]
+	if !ok {
+		return nil, fmt.Errorf("unknown app: %s", appID)
+	}
+	return client, nil
+}
+
+// Remove will remove the app
+func (r *AppRegistry) Remove(appID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, appID)
+}
+
+// AppIDs will return the IDs of all the registered apps (sorted)
+func (r *AppRegistry) AppIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedKeys(r.clients)
+}
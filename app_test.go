@@ -0,0 +1,136 @@
+package handcash
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHTTPAppHeaders records the app headers of the request
+type mockHTTPAppHeaders struct {
+	mockHTTPGetProfile
+	appID     string
+	appSecret string
+}
+
+// Do is a mock http request
+func (m *mockHTTPAppHeaders) Do(req *http.Request) (*http.Response, error) {
+	m.appID = req.Header.Get(headerAppID)
+	m.appSecret = req.Header.Get(headerAppSecret)
+	return m.mockHTTPGetProfile.Do(req)
+}
+
+// newTestApp returns a valid app
+func newTestApp(appID string) *App {
+	return &App{AppID: appID, AppSecret: "secret-" + appID, Environment: EnvironmentBeta}
+}
+
+func TestNewAppClient(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid apps", func(t *testing.T) {
+		for _, app := range []*App{
+			nil,
+			{AppSecret: "secret", Environment: EnvironmentBeta},
+			{AppID: "app", Environment: EnvironmentBeta},
+			{AppID: "app", AppSecret: "secret", Environment: "bta"},
+		} {
+			client, err := NewAppClient(app, nil, nil)
+			assert.Error(t, err)
+			assert.Nil(t, client)
+		}
+	})
+
+	t.Run("app headers are sent", func(t *testing.T) {
+		client, err := NewAppClient(newTestApp("app-1"), nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, environments[EnvironmentBeta], client.Environment)
+
+		mock := &mockHTTPAppHeaders{}
+		client.httpClient = mock
+		_, err = client.GetProfile(context.Background(), "000000")
+		require.NoError(t, err)
+		assert.Equal(t, "app-1", mock.appID)
+		assert.Equal(t, "secret-app-1", mock.appSecret)
+	})
+
+	t.Run("no app headers without an app", func(t *testing.T) {
+		mock := &mockHTTPAppHeaders{}
+		client := newTestClient(mock, EnvironmentBeta)
+		_, err := client.GetProfile(context.Background(), "000000")
+		require.NoError(t, err)
+		assert.Empty(t, mock.appID)
+		assert.Empty(t, mock.appSecret)
+		assert.Nil(t, client.App())
+	})
+
+	t.Run("app is copied", func(t *testing.T) {
+		app := newTestApp("app-1")
+		client, err := NewAppClient(app, nil, nil)
+		require.NoError(t, err)
+
+		app.AppSecret = "changed"
+		client.App().AppSecret = "changed"
+		assert.Equal(t, "secret-app-1", client.App().AppSecret)
+	})
+
+	t.Run("redirection url", func(t *testing.T) {
+		client, err := NewAppClient(newTestApp("app-1"), nil, nil)
+		require.NoError(t, err)
+
+		var redirectURL string
+		redirectURL, err = client.GetAppRedirectionURL(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "https://beta-app.handcash.io/#/authorizeApp?appId=app-1", redirectURL)
+
+		_, err = newTestClient(nil, EnvironmentBeta).GetAppRedirectionURL(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("app secret is never logged", func(t *testing.T) {
+		headers := http.Header{}
+		headers.Set(headerAppSecret, "secret")
+		assert.Equal(t, redactedValue, redactHeaders(headers)["App-Secret"])
+	})
+}
+
+func TestAppRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := NewAppRegistry(nil, nil)
+
+	// Register apps
+	first, err := registry.Register(newTestApp("app-2"))
+	require.NoError(t, err)
+	_, err = registry.Register(newTestApp("app-1"))
+	require.NoError(t, err)
+	_, err = registry.Register(&App{AppID: "app-3"})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"app-1", "app-2"}, registry.AppIDs())
+
+	// Get a client
+	var client *Client
+	client, err = registry.Client("app-2")
+	require.NoError(t, err)
+	assert.Equal(t, first, client)
+	assert.Equal(t, "app-2", client.App().AppID)
+
+	_, err = registry.Client("app-3")
+	assert.Error(t, err)
+
+	// Replace and remove
+	var replaced *Client
+	replaced, err = registry.Register(newTestApp("app-2"))
+	require.NoError(t, err)
+	client, err = registry.Client("app-2")
+	require.NoError(t, err)
+	assert.Equal(t, replaced, client)
+
+	registry.Remove("app-2")
+	_, err = registry.Client("app-2")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"app-1"}, registry.AppIDs())
+}
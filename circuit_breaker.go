@@ -0,0 +1,211 @@
+package handcash
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned (without sending the request) when the circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker
+type CircuitState int
+
+// Circuit breaker states
+const (
+	CircuitClosed   CircuitState = iota // Requests are sent
+	CircuitOpen                         // Requests fail with ErrCircuitOpen
+	CircuitHalfOpen                     // Probe requests are sent to test recovery
+)
+
+// String will return the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerOptions configure the circuit breaker around the Connect API
+//
+// Network errors, timeouts and 5xx responses are failures. The circuit opens after
+// FailureThreshold consecutive failures, and after OpenTimeout it lets up to
+// HalfOpenMaxRequests probes through: SuccessThreshold successful probes close
+// the circuit again, and any failed probe opens it again.
+type CircuitBreakerOptions struct {
+	FailureThreshold    int                         // Consecutive failures to open the circuit (default: 5)
+	HalfOpenMaxRequests int                         // Probes allowed at once when half-open (default: 1)
+	OnStateChange       func(from, to CircuitState) // Optional callback for every state change (IE: alerting)
+	OpenTimeout         time.Duration               // Time before probing an open circuit (default: 30s)
+	SuccessThreshold    int                         // Successful probes to close the circuit (default: 1)
+}
+
+// Default circuit breaker options
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenTimeout      = 30 * time.Second
+)
+
+// circuitBreaker is the circuit breaker state machine
+type circuitBreaker struct {
+	failures  int // Consecutive failures (closed)
+	mu        sync.Mutex
+	openedAt  time.Time // When the circuit was opened
+	options   CircuitBreakerOptions
+	probes    int // Probes in flight (half-open)
+	state     CircuitState
+	successes int // Successful probes (half-open)
+}
+
+// newCircuitBreaker will return a circuit breaker for the options (nil if not set)
+func newCircuitBreaker(options *CircuitBreakerOptions) *circuitBreaker {
+	if options == nil {
+		return nil
+	}
+	cb := &circuitBreaker{options: *options}
+	if cb.options.FailureThreshold <= 0 {
+		cb.options.FailureThreshold = defaultCircuitFailureThreshold
+	}
+	if cb.options.HalfOpenMaxRequests <= 0 {
+		cb.options.HalfOpenMaxRequests = 1
+	}
+	if cb.options.OpenTimeout <= 0 {
+		cb.options.OpenTimeout = defaultCircuitOpenTimeout
+	}
+	if cb.options.SuccessThreshold <= 0 {
+		cb.options.SuccessThreshold = 1
+	}
+	return cb
+}
+
+// allow will return ErrCircuitOpen if the request cannot be sent
+func (cb *circuitBreaker) allow() error {
+	if cb == nil {
+		return nil
+	}
+
+	cb.mu.Lock()
+	notify := func() {}
+
+	// Start probing once the open timeout has passed
+	if cb.state == CircuitOpen {
+		if time.Since(cb.openedAt) < cb.options.OpenTimeout {
+			cb.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		notify = cb.setState(CircuitHalfOpen)
+	}
+
+	// Limit the probes
+	var err error
+	if cb.state == CircuitHalfOpen {
+		if cb.probes >= cb.options.HalfOpenMaxRequests {
+			err = ErrCircuitOpen
+		} else {
+			cb.probes++
+		}
+	}
+	cb.mu.Unlock()
+
+	notify()
+	return err
+}
+
+// record will record the result of an allowed request
+func (cb *circuitBreaker) record(ctx context.Context, resp *http.Response, err error) {
+	if cb == nil {
+		return
+	}
+
+	// A request the caller gave up on says nothing about the API
+	failed := isCircuitFailure(ctx, resp, err)
+	if !failed && err != nil {
+		cb.release()
+		return
+	}
+
+	cb.mu.Lock()
+	notify := func() {}
+	switch cb.state {
+	case CircuitClosed:
+		if !failed {
+			cb.failures = 0
+		} else if cb.failures++; cb.failures >= cb.options.FailureThreshold {
+			notify = cb.setState(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if failed {
+			notify = cb.setState(CircuitOpen)
+		} else if cb.successes++; cb.successes >= cb.options.SuccessThreshold {
+			notify = cb.setState(CircuitClosed)
+		} else if cb.probes > 0 {
+			cb.probes--
+		}
+	case CircuitOpen:
+		// A request that started before the circuit opened
+	}
+	cb.mu.Unlock()
+
+	notify()
+}
+
+// release will free the probe of an allowed request that was never sent (or was canceled)
+func (cb *circuitBreaker) release() {
+	if cb == nil {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == CircuitHalfOpen && cb.probes > 0 {
+		cb.probes--
+	}
+}
+
+// setState will change the state and reset the counters (the lock must be held)
+//
+// The returned function fires the callback, and must be called after unlocking
+func (cb *circuitBreaker) setState(state CircuitState) (notify func()) {
+	from := cb.state
+	cb.state = state
+	cb.failures = 0
+	cb.probes = 0
+	cb.successes = 0
+	if state == CircuitOpen {
+		cb.openedAt = time.Now()
+	}
+	return func() {
+		if cb.options.OnStateChange != nil {
+			cb.options.OnStateChange(from, state)
+		}
+	}
+}
+
+// isCircuitFailure will return true if the result counts as a failure
+// (network errors, timeouts and 5xx responses, unless the caller gave up)
+func isCircuitFailure(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil
+	}
+	return resp != nil && resp.StatusCode >= http.StatusInternalServerError
+}
+
+// CircuitState will return the current state of the circuit breaker
+// (always closed if the circuit breaker is not enabled)
+func (c *Client) CircuitState() CircuitState {
+	if c.circuitBreaker == nil {
+		return CircuitClosed
+	}
+	c.circuitBreaker.mu.Lock()
+	defer c.circuitBreaker.mu.Unlock()
+	return c.circuitBreaker.state
+}
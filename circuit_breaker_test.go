@@ -0,0 +1,232 @@
+package handcash
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// circuitChanges records the state changes of a circuit breaker
+type circuitChanges struct {
+	changes []string
+	mu      sync.Mutex
+}
+
+// record will record the state change
+func (c *circuitChanges) record(from, to CircuitState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changes = append(c.changes, from.String()+"->"+to.String())
+}
+
+// list will return the state changes
+func (c *circuitChanges) list() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.changes...)
+}
+
+func TestCircuitState_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "closed", CircuitClosed.String())
+	assert.Equal(t, "open", CircuitOpen.String())
+	assert.Equal(t, "half-open", CircuitHalfOpen.String())
+	assert.Equal(t, "unknown", CircuitState(99).String())
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	failure := &http.Response{StatusCode: http.StatusBadGateway}
+	success := &http.Response{StatusCode: http.StatusOK}
+
+	t.Run("defaults", func(t *testing.T) {
+		assert.Nil(t, newCircuitBreaker(nil))
+
+		cb := newCircuitBreaker(&CircuitBreakerOptions{})
+		assert.Equal(t, defaultCircuitFailureThreshold, cb.options.FailureThreshold)
+		assert.Equal(t, defaultCircuitOpenTimeout, cb.options.OpenTimeout)
+		assert.Equal(t, 1, cb.options.HalfOpenMaxRequests)
+		assert.Equal(t, 1, cb.options.SuccessThreshold)
+
+		// A nil circuit breaker always allows requests
+		var disabled *circuitBreaker
+		assert.NoError(t, disabled.allow())
+		disabled.record(context.Background(), failure, nil)
+	})
+
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		changes := new(circuitChanges)
+		cb := newCircuitBreaker(&CircuitBreakerOptions{FailureThreshold: 3, OnStateChange: changes.record})
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, cb.allow())
+			cb.record(context.Background(), failure, nil)
+		}
+		require.NoError(t, cb.allow())
+		cb.record(context.Background(), success, nil)
+		assert.Equal(t, 0, cb.failures)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, cb.allow())
+			cb.record(context.Background(), nil, fmt.Errorf("connection refused"))
+		}
+		assert.Equal(t, CircuitOpen, cb.state)
+		assert.ErrorIs(t, cb.allow(), ErrCircuitOpen)
+		assert.Equal(t, []string{"closed->open"}, changes.list())
+	})
+
+	t.Run("client errors are not failures", func(t *testing.T) {
+		cb := newCircuitBreaker(&CircuitBreakerOptions{FailureThreshold: 1})
+		require.NoError(t, cb.allow())
+		cb.record(context.Background(), &http.Response{StatusCode: http.StatusBadRequest}, nil)
+		assert.Equal(t, CircuitClosed, cb.state)
+	})
+
+	t.Run("canceled requests are not failures", func(t *testing.T) {
+		cb := newCircuitBreaker(&CircuitBreakerOptions{FailureThreshold: 1})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, cb.allow())
+		cb.record(ctx, nil, context.Canceled)
+		assert.Equal(t, CircuitClosed, cb.state)
+	})
+
+	t.Run("half-open probe closes the circuit", func(t *testing.T) {
+		changes := new(circuitChanges)
+		cb := newCircuitBreaker(&CircuitBreakerOptions{
+			FailureThreshold: 1,
+			OnStateChange:    changes.record,
+			OpenTimeout:      10 * time.Millisecond,
+		})
+		require.NoError(t, cb.allow())
+		cb.record(context.Background(), failure, nil)
+		assert.ErrorIs(t, cb.allow(), ErrCircuitOpen)
+
+		time.Sleep(15 * time.Millisecond)
+		require.NoError(t, cb.allow())
+		assert.Equal(t, CircuitHalfOpen, cb.state)
+
+		// Only one probe at a time
+		assert.ErrorIs(t, cb.allow(), ErrCircuitOpen)
+
+		cb.record(context.Background(), success, nil)
+		assert.Equal(t, CircuitClosed, cb.state)
+		assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, changes.list())
+	})
+
+	t.Run("half-open probe failure opens the circuit", func(t *testing.T) {
+		cb := newCircuitBreaker(&CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: 10 * time.Millisecond})
+		require.NoError(t, cb.allow())
+		cb.record(context.Background(), failure, nil)
+
+		time.Sleep(15 * time.Millisecond)
+		require.NoError(t, cb.allow())
+		cb.record(context.Background(), failure, nil)
+		assert.Equal(t, CircuitOpen, cb.state)
+		assert.ErrorIs(t, cb.allow(), ErrCircuitOpen)
+	})
+
+	t.Run("success threshold", func(t *testing.T) {
+		cb := newCircuitBreaker(&CircuitBreakerOptions{
+			FailureThreshold:    1,
+			HalfOpenMaxRequests: 2,
+			OpenTimeout:         10 * time.Millisecond,
+			SuccessThreshold:    2,
+		})
+		require.NoError(t, cb.allow())
+		cb.record(context.Background(), failure, nil)
+
+		time.Sleep(15 * time.Millisecond)
+		require.NoError(t, cb.allow())
+		require.NoError(t, cb.allow())
+		cb.record(context.Background(), success, nil)
+		assert.Equal(t, CircuitHalfOpen, cb.state)
+		cb.record(context.Background(), success, nil)
+		assert.Equal(t, CircuitClosed, cb.state)
+	})
+
+	t.Run("released probe", func(t *testing.T) {
+		cb := newCircuitBreaker(&CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: 10 * time.Millisecond})
+		require.NoError(t, cb.allow())
+		cb.record(context.Background(), failure, nil)
+
+		time.Sleep(15 * time.Millisecond)
+		require.NoError(t, cb.allow())
+		cb.release()
+		require.NoError(t, cb.allow())
+	})
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	changes := new(circuitChanges)
+	options := DefaultClientOptions()
+	options.CircuitBreaker = &CircuitBreakerOptions{FailureThreshold: 2, OnStateChange: changes.record}
+	options.RequestRetryCount = 0
+	client := NewClient(options, nil, EnvironmentBeta)
+	client.Environment = &Environment{APIURL: server.URL, Environment: "test"}
+	assert.Equal(t, CircuitClosed, client.CircuitState())
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetProfile(context.Background(), "000000")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+	assert.Equal(t, CircuitOpen, client.CircuitState())
+	assert.Equal(t, []string{"closed->open"}, changes.list())
+
+	// Fails immediately
+	_, err := client.GetProfile(context.Background(), "000000")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// Disabled by default
+	assert.Equal(t, CircuitClosed, newTestClient(&mockHTTPGetProfile{}, EnvironmentBeta).CircuitState())
+}
+
+func TestClient_CircuitBreakerBeforeRateLimit(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	options := DefaultClientOptions()
+	options.CircuitBreaker = &CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Minute}
+	options.RateLimit = &RateLimitOptions{Global: RateLimit{Burst: 1, Rate: 0.01}}
+	options.RequestRetryCount = 0
+	client := NewClient(options, nil, EnvironmentBeta)
+	client.Environment = &Environment{APIURL: server.URL, Environment: "test"}
+
+	_, err := client.GetProfile(context.Background(), "000000")
+	require.Error(t, err)
+	assert.Equal(t, CircuitOpen, client.CircuitState())
+
+	// Fails immediately (without waiting for the rate limiter or using its tokens)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err = client.GetProfile(ctx, "000000")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Less(t, client.rateLimiter.global.tokens, float64(0.1))
+}
@@ -1,12 +1,13 @@
 package handcash
 
 import (
+	"log/slog"
 	"net"
 	"net/http"
 	"time"
 
-	"github.com/gojektech/heimdall/v6"
 	"github.com/gojektech/heimdall/v6/httpclient"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // httpInterface is used for the http client (mocking heimdall)
@@ -16,26 +17,44 @@ type httpInterface interface {
 
 // Client is the parent struct that contains the miner clients and list of miners to use
 type Client struct {
-	Environment *Environment   // Current environment for the client
-	httpClient  httpInterface  // Interface for all HTTP requests
-	Options     *ClientOptions // Client options config
+	app              *App             // App of the client (nil if not created for an app)
+	circuitBreaker   *circuitBreaker  // Circuit breaker for all HTTP requests (nil if not enabled)
+	Environment      *Environment     // Current environment for the client
+	environmentErr   error            // Unknown environment name (every request fails with it)
+	httpClient       httpInterface    // Interface for all HTTP requests
+	idempotencyKeys  keyLocker        // Serializes payments with the same idempotency key
+	idempotencyStore IdempotencyStore // Payments made for each idempotency key
+	middleware       []Middleware     // Middleware wrapping all HTTP requests
+	Options          *ClientOptions   // Client options config
+	rateLimiter      *rateLimiter     // Rate limiter for all HTTP requests (nil if not limited)
+	retryPolicy      *RetryPolicy     // Retry policy for all HTTP requests
+	tokenStore       TokenStore       // Auth tokens of the users (for the ForUser methods)
+	tracer           trace.Tracer     // Tracer for all client methods (noop if not set)
 }
 
 // ClientOptions holds all the configuration for connection, dialer and transport
 type ClientOptions struct {
-	BackOffExponentFactor          float64       `json:"back_off_exponent_factor"`
-	BackOffInitialTimeout          time.Duration `json:"back_off_initial_timeout"`
-	BackOffMaximumJitterInterval   time.Duration `json:"back_off_maximum_jitter_interval"`
-	BackOffMaxTimeout              time.Duration `json:"back_off_max_timeout"`
-	DialerKeepAlive                time.Duration `json:"dialer_keep_alive"`
-	DialerTimeout                  time.Duration `json:"dialer_timeout"`
-	RequestRetryCount              int           `json:"request_retry_count"`
-	RequestTimeout                 time.Duration `json:"request_timeout"`
-	TransportExpectContinueTimeout time.Duration `json:"transport_expect_continue_timeout"`
-	TransportIdleTimeout           time.Duration `json:"transport_idle_timeout"`
-	TransportMaxIdleConnections    int           `json:"transport_max_idle_connections"`
-	TransportTLSHandshakeTimeout   time.Duration `json:"transport_tls_handshake_timeout"`
-	UserAgent                      string        `json:"user_agent"`
+	BackOffExponentFactor          float64                `json:"back_off_exponent_factor"`
+	BackOffInitialTimeout          time.Duration          `json:"back_off_initial_timeout"`
+	BackOffMaximumJitterInterval   time.Duration          `json:"back_off_maximum_jitter_interval"`
+	BackOffMaxTimeout              time.Duration          `json:"back_off_max_timeout"`
+	CircuitBreaker                 *CircuitBreakerOptions `json:"-"` // Optional circuit breaker for all requests
+	DialerKeepAlive                time.Duration          `json:"dialer_keep_alive"`
+	DialerTimeout                  time.Duration          `json:"dialer_timeout"`
+	IdempotencyStore               IdempotencyStore       `json:"-"`          // Optional store for payment idempotency keys (in-memory by default)
+	Logger                         *slog.Logger           `json:"-"`          // Optional logging of every request (secrets are redacted)
+	MetricsCollector               MetricsCollector       `json:"-"`          // Optional metrics for every request
+	RateLimit                      *RateLimitOptions      `json:"rate_limit"` // Optional client-side rate limits
+	RequestRetryCount              int                    `json:"request_retry_count"`
+	RequestTimeout                 time.Duration          `json:"request_timeout"`
+	RetryPolicy                    *RetryPolicy           `json:"retry_policy"` // Optional (the back-off options and retry count are used if not set)
+	TokenStore                     TokenStore             `json:"-"`            // Optional store for the auth tokens of the users (in-memory by default)
+	TransportExpectContinueTimeout time.Duration          `json:"transport_expect_continue_timeout"`
+	TransportIdleTimeout           time.Duration          `json:"transport_idle_timeout"`
+	TransportMaxIdleConnections    int                    `json:"transport_max_idle_connections"`
+	TransportTLSHandshakeTimeout   time.Duration          `json:"transport_tls_handshake_timeout"`
+	TracerProvider                 trace.TracerProvider   `json:"-"` // Optional OpenTelemetry tracing
+	UserAgent                      string                 `json:"user_agent"`
 }
 
 // DefaultClientOptions will return an Options struct with the default settings.
@@ -65,13 +84,36 @@ type Environment struct {
 	Environment string `json:"environment"`
 }
 
-// NewClient creates a new client for requests
+// NewClient creates a new client for requests (see New() for the functional options)
 // If no environment is set, production is used as the default
+//
+// An unknown environment name never falls back to production: every request made by the
+// client fails with the "unknown environment" error. Use New(WithEnvironment(name)) to get
+// the error when the client is created.
 func NewClient(options *ClientOptions, customHTTPClient *http.Client,
 	customEnvironment string) (c *Client) {
 
+	// Default to production
+	if len(customEnvironment) == 0 {
+		customEnvironment = EnvironmentProduction
+	}
+
+	// Get the environment
+	environment, err := GetEnvironment(customEnvironment)
+	if err != nil {
+		c = newClient(options, customHTTPClient, &Environment{Environment: customEnvironment})
+		c.environmentErr = err
+		return
+	}
+	return newClient(options, customHTTPClient, environment)
+}
+
+// newClient creates a new client for requests to the environment
+func newClient(options *ClientOptions, customHTTPClient *http.Client,
+	environment *Environment) (c *Client) {
+
 	// Create a client
-	c = new(Client)
+	c = &Client{Environment: environment}
 
 	// Set options (either default or user modified)
 	if options == nil {
@@ -81,10 +123,30 @@ func NewClient(options *ClientOptions, customHTTPClient *http.Client,
 	// Set the options
 	c.Options = options
 
-	// Set the environment
-	var found bool
-	if c.Environment, found = environments[customEnvironment]; !found {
-		c.Environment = environments[EnvironmentProduction]
+	// Set the tracer (if tracing is enabled)
+	if options.TracerProvider != nil {
+		c.tracer = options.TracerProvider.Tracer(tracerName, trace.WithInstrumentationVersion(version))
+	}
+
+	// Set the idempotency store
+	if c.idempotencyStore = options.IdempotencyStore; c.idempotencyStore == nil {
+		c.idempotencyStore = NewMemoryIdempotencyStore(DefaultIdempotencyTTL)
+	}
+
+	// Set the token store
+	if c.tokenStore = options.TokenStore; c.tokenStore == nil {
+		c.tokenStore = NewMemoryTokenStore()
+	}
+
+	// Set the circuit breaker (if enabled)
+	c.circuitBreaker = newCircuitBreaker(options.CircuitBreaker)
+
+	// Set the rate limiter (if rate limits are set)
+	c.rateLimiter = newRateLimiter(options.RateLimit)
+
+	// Set the retry policy (or use the back-off options)
+	if c.retryPolicy = options.RetryPolicy; c.retryPolicy == nil {
+		c.retryPolicy = retryPolicyFromOptions(options)
 	}
 
 	// Is there a custom HTTP client to use?
@@ -106,31 +168,9 @@ func NewClient(options *ClientOptions, customHTTPClient *http.Client,
 		TLSHandshakeTimeout:   options.TransportTLSHandshakeTimeout,
 	}
 
-	// Determine the strategy for the http client
-	if options.RequestRetryCount <= 0 {
-
-		// no retry enabled
-		c.httpClient = httpclient.NewClient(
-			httpclient.WithHTTPTimeout(options.RequestTimeout),
-			httpclient.WithHTTPClient(&http.Client{
-				Transport: clientDefaultTransport,
-				Timeout:   options.RequestTimeout,
-			}),
-		)
-		return
-	}
-
-	// Retry enabled - create exponential back-off
+	// Retries are handled by the retry policy (not the http client)
 	c.httpClient = httpclient.NewClient(
 		httpclient.WithHTTPTimeout(options.RequestTimeout),
-		httpclient.WithRetrier(heimdall.NewRetrier(
-			heimdall.NewExponentialBackoff(
-				options.BackOffInitialTimeout,
-				options.BackOffMaxTimeout,
-				options.BackOffExponentFactor,
-				options.BackOffMaximumJitterInterval,
-			))),
-		httpclient.WithRetryCount(options.RequestRetryCount),
 		httpclient.WithHTTPClient(&http.Client{
 			Transport: clientDefaultTransport,
 			Timeout:   options.RequestTimeout,
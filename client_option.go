@@ -0,0 +1,141 @@
+package handcash
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ClientOption configures the client created by New()
+type ClientOption func(c *clientConfig) error
+
+// clientConfig is the configuration built by the client options
+type clientConfig struct {
+	environment *Environment
+	httpClient  *http.Client
+	options     *ClientOptions
+}
+
+// New creates a new client for requests using the functional options
+//
+// The options are applied in order and validated before the client is created. If no
+// environment is set, production is used as the default.
+func New(opts ...ClientOption) (*Client, error) {
+	config := &clientConfig{options: DefaultClientOptions()}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(config); err != nil {
+			return nil, fmt.Errorf("invalid client option: %w", err)
+		}
+	}
+	if config.environment == nil {
+		config.environment, _ = GetEnvironment(EnvironmentProduction)
+	}
+	return newClient(config.options, config.httpClient, config.environment), nil
+}
+
+// WithClientOptions will use a copy of the options (replacing any options set before it)
+//
+// Useful for the options without a ClientOption (IE: TracerProvider or RateLimit)
+func WithClientOptions(options *ClientOptions) ClientOption {
+	return func(c *clientConfig) error {
+		if options == nil {
+			return fmt.Errorf("missing client options")
+		}
+		optionsCopy := *options
+		c.options = &optionsCopy
+		return nil
+	}
+}
+
+// WithEnvironment will use the environment (an error if the name is unknown)
+func WithEnvironment(name string) ClientOption {
+	return func(c *clientConfig) (err error) {
+		c.environment, err = GetEnvironment(name)
+		return
+	}
+}
+
+// WithCustomEnvironment will use the environment (it does not need to be registered)
+func WithCustomEnvironment(environment *Environment) ClientOption {
+	return func(c *clientConfig) (err error) {
+		c.environment, err = newEnvironment(environment)
+		return
+	}
+}
+
+// WithHTTPClient will use the HTTP client for all requests (instead of the default client)
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *clientConfig) error {
+		if httpClient == nil {
+			return fmt.Errorf("missing http client")
+		}
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithUserAgent will set the user agent of all requests
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *clientConfig) error {
+		if len(userAgent) == 0 {
+			return fmt.Errorf("missing user agent")
+		}
+		c.options.UserAgent = userAgent
+		return nil
+	}
+}
+
+// WithRetryCount will set the max number of retries of a request (0 disables retries)
+func WithRetryCount(retries int) ClientOption {
+	return func(c *clientConfig) error {
+		if retries < 0 {
+			return fmt.Errorf("invalid retry count: %d", retries)
+		}
+		c.options.RequestRetryCount = retries
+
+		// Update the retry policy (if set)
+		if c.options.RetryPolicy != nil {
+			policy := *c.options.RetryPolicy
+			policy.MaxRetries = retries
+			c.options.RetryPolicy = &policy
+		}
+		return nil
+	}
+}
+
+// WithTimeout will set the timeout of each request attempt
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid timeout: %s", timeout)
+		}
+		c.options.RequestTimeout = timeout
+		return nil
+	}
+}
+
+// WithLogger will log every request (secrets are redacted)
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *clientConfig) error {
+		if logger == nil {
+			return fmt.Errorf("missing logger")
+		}
+		c.options.Logger = logger
+		return nil
+	}
+}
+
+// WithTokenStore will resolve the auth tokens of the ForUser methods from the store
+func WithTokenStore(store TokenStore) ClientOption {
+	return func(c *clientConfig) error {
+		if store == nil {
+			return fmt.Errorf("missing token store")
+		}
+		c.options.TokenStore = store
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+package handcash
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default client", func(t *testing.T) {
+		client, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, DefaultClientOptions(), client.Options)
+		assert.Equal(t, EnvironmentProduction, client.Environment.Environment)
+		assert.NotNil(t, client.httpClient)
+	})
+
+	t.Run("all options", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+		client, err := New(
+			WithEnvironment(EnvironmentBeta),
+			WithHTTPClient(http.DefaultClient),
+			WithUserAgent("test-agent"),
+			WithRetryCount(5),
+			WithTimeout(3*time.Second),
+			WithLogger(logger),
+			nil,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, EnvironmentBeta, client.Environment.Environment)
+		assert.Equal(t, http.DefaultClient, client.httpClient)
+		assert.Equal(t, "test-agent", client.Options.UserAgent)
+		assert.Equal(t, 5, client.Options.RequestRetryCount)
+		assert.Equal(t, 5, client.retryPolicy.MaxRetries)
+		assert.Equal(t, 3*time.Second, client.Options.RequestTimeout)
+		assert.Equal(t, logger, client.Options.Logger)
+	})
+
+	t.Run("client options are copied", func(t *testing.T) {
+		options := DefaultClientOptions()
+		options.RetryPolicy = DefaultRetryPolicy()
+
+		client, err := New(WithClientOptions(options), WithRetryCount(0), WithUserAgent("test-agent"))
+		require.NoError(t, err)
+		assert.Equal(t, 0, client.retryPolicy.MaxRetries)
+		assert.Equal(t, "test-agent", client.Options.UserAgent)
+		assert.Equal(t, DefaultRetryPolicy().MaxRetries, options.RetryPolicy.MaxRetries)
+		assert.Equal(t, defaultUserAgent, options.UserAgent)
+	})
+
+	t.Run("custom environment", func(t *testing.T) {
+		client, err := New(WithCustomEnvironment(&Environment{
+			APIURL:      "http://localhost:3000/",
+			ClientURL:   "http://localhost:3001",
+			Environment: "local",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:3000", client.Environment.APIURL)
+		assert.Equal(t, "local", client.Environment.Environment)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opt := range []ClientOption{
+			WithClientOptions(nil),
+			WithEnvironment("bta"),
+			WithCustomEnvironment(&Environment{APIURL: "localhost"}),
+			WithHTTPClient(nil),
+			WithUserAgent(""),
+			WithRetryCount(-1),
+			WithTimeout(0),
+			WithLogger(nil),
+		} {
+			client, err := New(opt)
+			assert.Error(t, err)
+			assert.Nil(t, client)
+		}
+	})
+
+	t.Run("unknown environment error", func(t *testing.T) {
+		_, err := New(WithEnvironment("bta"))
+		assert.EqualError(t, err, `invalid client option: unknown environment: "bta"`)
+	})
+}
+
+// ExampleNew example using New()
+func ExampleNew() {
+	client, err := New(WithEnvironment(EnvironmentBeta), WithUserAgent("my-app"))
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	fmt.Printf("created new client: %s", client.Options.UserAgent)
+	// Output:created new client: my-app
+}
+
+// BenchmarkNew benchmarks the method New()
+func BenchmarkNew(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = New(WithEnvironment(EnvironmentBeta))
+	}
+}
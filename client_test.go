@@ -2,6 +2,7 @@ package handcash
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -90,9 +91,15 @@ func TestNewClient(t *testing.T) {
 		assert.NotNil(t, client)
 		assert.NotNil(t, client.Options)
 		assert.NotNil(t, client.httpClient)
-		assert.Equal(t, environments[EnvironmentProduction].APIURL, client.Environment.APIURL)
-		assert.Equal(t, environments[EnvironmentProduction].ClientURL, client.Environment.ClientURL)
-		assert.Equal(t, EnvironmentProduction, client.Environment.Environment)
+		assert.Empty(t, client.Environment.APIURL)
+		assert.Empty(t, client.Environment.ClientURL)
+		assert.Equal(t, "unknown", client.Environment.Environment)
+
+		// Never sent to production
+		_, err := client.GetProfile(context.Background(), "000000")
+		assert.ErrorContains(t, err, `unknown environment: "unknown"`)
+		_, err = client.GetRedirectionURL("app-id", nil)
+		assert.EqualError(t, err, `unknown environment: "unknown"`)
 	})
 
 	t.Run("environment: empty", func(t *testing.T) {
@@ -13,6 +13,31 @@ const (
 
 	// emptyBody is the default body if nobody is set
 	emptyBody = "{}"
+
+	// maxHandlesPerRequest is the max number of handles sent in one public profiles request
+	maxHandlesPerRequest = 50
+
+	// maxReceiversPerPayment is the max number of receivers sent in one payment by PayBatch()
+	maxReceiversPerPayment = 100
+
+	// maxDescriptionLength is the max length of a payment description
+	maxDescriptionLength = 25
+
+	// defaultPayBatchConcurrency is the default number of payments made at once by PayBatch()
+	defaultPayBatchConcurrency = 4
+)
+
+// Query parameters used by the authorization redirect and callback
+const (
+	RedirectParamAuthToken = "authToken"
+	RedirectParamReferrer  = "referrerHandle"
+	RedirectParamState     = "state"
+
+	// redirectParamAppID is the app id query parameter
+	redirectParamAppID = "appId"
+
+	// redirectPathAuthorize is the client path for authorizing an app
+	redirectPathAuthorize = "/#/authorizeApp"
 )
 
 // Environments for Handcash
@@ -53,19 +78,19 @@ const (
 	endpointProfileCurrent = endpointProfile + "/currentUserProfile"
 
 	// endpointPublicProfilesByHandle will return profiles given list of handles
-	// endpointPublicProfilesByHandle = endpointProfile + "/publicUserProfiles"
+	endpointPublicProfilesByHandle = endpointProfile + "/publicUserProfiles"
 
 	// endpointGetFriends will return a list of friends
-	// endpointGetFriends = endpointProfile + "/friends"
+	endpointGetFriends = endpointProfile + "/friends"
 
 	// endpointGetPermissions will return a list of permissions for the user
-	// endpointGetPermissions = endpointProfile + "/permissions"
+	endpointGetPermissions = endpointProfile + "/permissions"
 
 	// endpointGetEncryptionKeypair will return the public key
-	// endpointGetEncryptionKeypair = endpointProfile + "/encryptionKeypair"
+	endpointGetEncryptionKeypair = endpointProfile + "/encryptionKeypair"
 
 	// endpointSignData will sign given data
-	// endpointSignData = endpointProfile + "/signData"
+	endpointSignData = endpointProfile + "/signData"
 
 	// endpointWallet is for accessing wallet information
 	endpointWallet = "/" + apiVersion + "/connect/wallet"
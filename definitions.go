@@ -49,6 +49,27 @@ type PublicProfile struct {
 	BitcoinUnit       string       `json:"bitcoinUnit"`
 }
 
+// PublicProfilesRequest is used for GetPublicProfilesByHandle()
+type PublicProfilesRequest struct {
+	Aliases []string `json:"aliases"`
+}
+
+// publicProfilesResponse is returned from the public profiles and friends endpoints
+type publicProfilesResponse struct {
+	Items []*PublicProfile `json:"items"`
+}
+
+// EncryptionKeypairRequest is used for GetEncryptionKeypair()
+type EncryptionKeypairRequest struct {
+	EncryptionPublicKey string `json:"encryptionPublicKey"`
+}
+
+// encryptionKeypairResponse is returned from the encryption keypair endpoint
+type encryptionKeypairResponse struct {
+	EncryptedPrivateKeyHex string `json:"encryptedPrivateKeyHex"`
+	EncryptedPublicKeyHex  string `json:"encryptedPublicKeyHex"`
+}
+
 // PrivateProfile is the private profile
 type PrivateProfile struct {
 	Email       string `json:"email"`
@@ -80,6 +101,15 @@ type PaymentResponse struct {
 	Type              PaymentType    `json:"type"`
 }
 
+// TransactionOutput is an output expected in the raw transaction of a payment
+//
+// Set the Address (P2PKH) or the LockingScript (hex) of the receiver
+type TransactionOutput struct {
+	Address       string `json:"address,omitempty"`
+	LockingScript string `json:"lockingScript,omitempty"`
+	Satoshis      uint64 `json:"satoshis"`
+}
+
 // PaymentRequest is used for GetPayment()
 type PaymentRequest struct {
 	TransactionID string `json:"transactionId"`
@@ -101,6 +131,27 @@ const (
 	AppActionTipGroup AppAction = "tip-group"
 )
 
+// Permission enum
+type Permission string
+
+// Permission enum
+const (
+	PermissionDecrypt            Permission = "DECRYPT"
+	PermissionFriends            Permission = "FRIENDS"
+	PermissionPay                Permission = "PAY"
+	PermissionSignData           Permission = "SIGN_DATA"
+	PermissionUserPrivateProfile Permission = "USER_PRIVATE_PROFILE"
+	PermissionUserPublicProfile  Permission = "USER_PUBLIC_PROFILE"
+)
+
+// PermissionSet is the list of permissions granted to an auth token
+type PermissionSet []Permission
+
+// permissionsResponse is returned from the permissions endpoint
+type permissionsResponse struct {
+	Items PermissionSet `json:"items"`
+}
+
 // AttachmentFormat enum
 type AttachmentFormat string
 
@@ -111,6 +162,28 @@ const (
 	AttachmentFormatJSON   AttachmentFormat = "json"
 )
 
+// DataFormat enum
+type DataFormat string
+
+// DataFormat enum
+const (
+	DataFormatBase64 DataFormat = "base64"
+	DataFormatHex    DataFormat = "hex"
+	DataFormatUTF8   DataFormat = "utf-8"
+)
+
+// SignDataRequest is used for SignData()
+type SignDataRequest struct {
+	Format DataFormat `json:"format"`
+	Value  string     `json:"value"`
+}
+
+// SignDataResponse is returned from the SignData function
+type SignDataResponse struct {
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
 // Attachment is for additional data
 type Attachment struct {
 	Format AttachmentFormat `json:"format,omitempty"`
@@ -125,11 +198,45 @@ type Payment struct {
 }
 
 // PayParameters is used by Pay()
+//
+// The IdempotencyKey is never sent to HandCash: it is only used by the client to return
+// the original payment for a repeated key, or ErrPaymentOutcomeUnknown if the payment may
+// have been made (payments are never retried)
 type PayParameters struct {
-	AppAction   AppAction   `json:"appAction,omitempty"`
-	Attachment  *Attachment `json:"attachment,omitempty"`
-	Description string      `json:"description,omitempty"`
-	Receivers   []*Payment  `json:"receivers,omitempty"`
+	AppAction      AppAction   `json:"appAction,omitempty"`
+	Attachment     *Attachment `json:"attachment,omitempty"`
+	Description    string      `json:"description,omitempty"`
+	IdempotencyKey string      `json:"-"`
+	Receivers      []*Payment  `json:"receivers,omitempty"`
+}
+
+// PayBatchOptions is used by PayBatch()
+//
+// The app action, attachment and description are added to every payment. If the
+// IdempotencyKey is set, each payment uses the key with the index of its chunk of the
+// receivers slice (IE: key-0, key-1): a batch run again with the same key returns the
+// payments already made, and a chunk whose receivers changed fails with
+// ErrIdempotencyKeyReused instead of paying twice
+type PayBatchOptions struct {
+	AppAction           AppAction   `json:"appAction,omitempty"`
+	Attachment          *Attachment `json:"attachment,omitempty"`
+	Concurrency         int         `json:"concurrency"`
+	Description         string      `json:"description,omitempty"`
+	IdempotencyKey      string      `json:"idempotencyKey,omitempty"`
+	ReceiversPerPayment int         `json:"receiversPerPayment"`
+}
+
+// PayBatchReport is returned by PayBatch()
+type PayBatchReport struct {
+	Payments []*PaymentResponse `json:"payments"` // Successful payments (in order)
+	Results  []*PayBatchResult  `json:"results"`  // Result per receiver (same order as the receivers)
+}
+
+// PayBatchResult is the result of paying a single receiver in PayBatch()
+type PayBatchResult struct {
+	Error         error    `json:"-"`
+	Receiver      *Payment `json:"receiver"`
+	TransactionID string   `json:"transactionId,omitempty"`
 }
 
 // PaymentType enum
@@ -157,11 +264,15 @@ type oAuthHeaders struct {
 
 // signedRequest is used to communicate with HandCash Connect API
 type signedRequest struct {
-	Body    interface{}  `json:"body"`
-	Headers oAuthHeaders `json:"headers"`
-	JSON    bool         `json:"json"`
-	Method  string       `json:"method"`
-	URI     string       `json:"uri"`
+	Body     interface{}  `json:"body"`
+	Endpoint string       `json:"endpoint"`
+	Headers  oAuthHeaders `json:"headers"`
+	JSON     bool         `json:"json"`
+	Method   string       `json:"method"`
+	URI      string       `json:"uri"`
+
+	// resign will sign the request again with the new timestamp (used for retries)
+	resign func(timestamp string) error
 }
 
 // requestBody is for constructing the request
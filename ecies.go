@@ -0,0 +1,126 @@
+package handcash
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+
+	"github.com/libsv/go-bk/bec"
+)
+
+const (
+
+	// eciesMagic is the prefix of every encrypted message (Electrum ECIES)
+	eciesMagic = "BIE1"
+
+	// eciesMinLength is the magic + ephemeral public key + one block + mac
+	eciesMinLength = len(eciesMagic) + 33 + aes.BlockSize + sha256.Size
+)
+
+// eciesEncrypt will encrypt the data for the given public key using Electrum ECIES
+// (compatible with the bsv JS library used by the HandCash Connect SDK)
+//
+// Format: "BIE1" | ephemeral public key (33) | AES-128-CBC ciphertext | HMAC-SHA256 (32)
+func eciesEncrypt(publicKey *bec.PublicKey, data []byte) ([]byte, error) {
+
+	// Create an ephemeral key for this message
+	ephemeral, err := bec.NewPrivateKey(bec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	// Derive the keys from the shared point
+	iv, keyE, keyM := eciesDeriveKeys(ephemeral, publicKey)
+
+	// Encrypt the padded data
+	var block cipher.Block
+	if block, err = aes.NewCipher(keyE); err != nil {
+		return nil, err
+	}
+	padded := eciesAddPadding(data)
+	cipherText := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(cipherText, padded)
+
+	// Build the message and append the mac
+	encrypted := append([]byte(eciesMagic), ephemeral.PubKey().SerialiseCompressed()...)
+	encrypted = append(encrypted, cipherText...)
+	mac := hmac.New(sha256.New, keyM)
+	_, _ = mac.Write(encrypted)
+	return mac.Sum(encrypted), nil
+}
+
+// eciesDecrypt will decrypt the data using the given private key (Electrum ECIES)
+func eciesDecrypt(privateKey *bec.PrivateKey, data []byte) ([]byte, error) {
+
+	// Check the length and magic
+	if len(data) < eciesMinLength {
+		return nil, fmt.Errorf("encrypted data is too short")
+	} else if !bytes.Equal(data[:len(eciesMagic)], []byte(eciesMagic)) {
+		return nil, fmt.Errorf("invalid encrypted data magic")
+	}
+
+	// Parse the ephemeral public key
+	ephemeral, err := bec.ParsePubKey(data[len(eciesMagic):len(eciesMagic)+33], bec.S256())
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %w", err)
+	}
+
+	// Derive the keys from the shared point
+	iv, keyE, keyM := eciesDeriveKeys(privateKey, ephemeral)
+
+	// Check the mac
+	macStart := len(data) - sha256.Size
+	mac := hmac.New(sha256.New, keyM)
+	_, _ = mac.Write(data[:macStart])
+	if !hmac.Equal(mac.Sum(nil), data[macStart:]) {
+		return nil, fmt.Errorf("invalid mac")
+	}
+
+	// Decrypt the cipher text
+	cipherText := data[len(eciesMagic)+33 : macStart]
+	if len(cipherText)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid cipher text length")
+	}
+	var block cipher.Block
+	if block, err = aes.NewCipher(keyE); err != nil {
+		return nil, err
+	}
+	plainText := make([]byte, len(cipherText))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plainText, cipherText)
+	return eciesRemovePadding(plainText)
+}
+
+// eciesDeriveKeys will return the iv, encryption key and mac key from the shared point
+func eciesDeriveKeys(privateKey *bec.PrivateKey, publicKey *bec.PublicKey) (iv, keyE, keyM []byte) {
+	x, y := bec.S256().ScalarMult(publicKey.X, publicKey.Y, privateKey.D.Bytes())
+	shared := &bec.PublicKey{Curve: bec.S256(), X: x, Y: y}
+	hash := sha512.Sum512(shared.SerialiseCompressed())
+	return hash[0:16], hash[16:32], hash[32:64]
+}
+
+// eciesAddPadding will add PKCS#7 padding to the data
+func eciesAddPadding(data []byte) []byte {
+	padding := aes.BlockSize - (len(data) % aes.BlockSize)
+	return append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+// eciesRemovePadding will remove PKCS#7 padding from the data
+func eciesRemovePadding(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > aes.BlockSize || padding > len(data) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, fmt.Errorf("invalid padding")
+		}
+	}
+	return data[:len(data)-padding], nil
+}
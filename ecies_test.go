@@ -0,0 +1,109 @@
+package handcash
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Electrum ECIES (BIE1) test vector for "attack at dawn" with a fixed ephemeral key
+//
+// Generated outside of Go by a Node.js (node:crypto) implementation of the bsv JS
+// Ecies.electrumEncrypt() steps, with the ephemeral key passed as fromKeyPair
+const (
+	testEciesEncrypted    = "QklFMQM55QTWSSsILaluEejwOXlrBs1IVcEB4kkqbxDz4Fap56+ajq0hzmnaQJXwUMZ/DUNgEx9i2TIhCA1mpBFIfxWZy+sH6H+sqqfX3sPHsGu0ug=="
+	testEciesEphemeralKey = "77e06abc52bf065cb5164c5deca839d0276911991a2730be4d8d0a0307de7ceb"
+	testEciesMessage      = "attack at dawn"
+	testEciesRecipientKey = "2b57c7c5e408ce927eef5e2efb49cfdadde77961d342daa72284bb3d6590862d"
+)
+
+func TestEcies(t *testing.T) {
+	t.Parallel()
+
+	privateKey, err := bec.NewPrivateKey(bec.S256())
+	assert.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, data := range []string{"", "a", "exactly16bytes!!", "some longer data that spans a few blocks"} {
+			encrypted, err := eciesEncrypt(privateKey.PubKey(), []byte(data))
+			assert.NoError(t, err)
+			assert.Equal(t, 0, (len(encrypted)-eciesMinLength)%16)
+
+			var decrypted []byte
+			decrypted, err = eciesDecrypt(privateKey, encrypted)
+			assert.NoError(t, err)
+			assert.Equal(t, data, string(decrypted))
+		}
+	})
+
+	t.Run("fixed test vector", func(t *testing.T) {
+		encrypted, err := base64.StdEncoding.DecodeString(testEciesEncrypted)
+		require.NoError(t, err)
+
+		var keyBytes []byte
+		keyBytes, err = hex.DecodeString(testEciesRecipientKey)
+		require.NoError(t, err)
+		recipient, _ := bec.PrivKeyFromBytes(bec.S256(), keyBytes)
+
+		var decrypted []byte
+		decrypted, err = eciesDecrypt(recipient, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, testEciesMessage, string(decrypted))
+
+		// The ephemeral public key follows the magic
+		keyBytes, err = hex.DecodeString(testEciesEphemeralKey)
+		require.NoError(t, err)
+		ephemeral, _ := bec.PrivKeyFromBytes(bec.S256(), keyBytes)
+		assert.Equal(t, ephemeral.PubKey().SerialiseCompressed(), encrypted[len(eciesMagic):len(eciesMagic)+33])
+	})
+
+	t.Run("too short", func(t *testing.T) {
+		decrypted, err := eciesDecrypt(privateKey, []byte(eciesMagic))
+		assert.Error(t, err)
+		assert.Nil(t, decrypted)
+	})
+
+	t.Run("invalid magic", func(t *testing.T) {
+		encrypted, err := eciesEncrypt(privateKey.PubKey(), []byte("hello"))
+		assert.NoError(t, err)
+		encrypted[0] = 'X'
+
+		var decrypted []byte
+		decrypted, err = eciesDecrypt(privateKey, encrypted)
+		assert.Error(t, err)
+		assert.Nil(t, decrypted)
+	})
+
+	t.Run("tampered data", func(t *testing.T) {
+		encrypted, err := eciesEncrypt(privateKey.PubKey(), []byte("hello"))
+		assert.NoError(t, err)
+		encrypted[len(encrypted)-40] ^= 0x01
+
+		var decrypted []byte
+		decrypted, err = eciesDecrypt(privateKey, encrypted)
+		assert.Error(t, err)
+		assert.Nil(t, decrypted)
+	})
+}
+
+func TestEciesRemovePadding(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid padding", func(t *testing.T) {
+		data, err := eciesRemovePadding(eciesAddPadding([]byte("hello")))
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("invalid padding", func(t *testing.T) {
+		for _, data := range [][]byte{{}, {0x00}, {0x11}, {0x01, 0x02}} {
+			unpadded, err := eciesRemovePadding(data)
+			assert.Error(t, err)
+			assert.Nil(t, unpadded)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package handcash
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/libsv/go-bk/bec"
+)
+
+/*
+{
+  "encryptedPublicKeyHex": "4249453102...",
+  "encryptedPrivateKeyHex": "4249453103..."
+}
+*/
+
+// EncryptionKeypair is the user's encryption key pair
+//
+// Data encrypted with the public key can only be decrypted with the user's private key
+type EncryptionKeypair struct {
+	PrivateKey *bec.PrivateKey
+	PublicKey  *bec.PublicKey
+}
+
+// GetEncryptionKeypair will get the user's encryption key pair (an ephemeral key is
+// generated locally and the key pair is returned encrypted for it)
+//
+// Specs: https://github.com/HandCash/handcash-connect-sdk-js/blob/master/src/profile/index.js
+func (c *Client) GetEncryptionKeypair(ctx context.Context, authToken string) (_ *EncryptionKeypair, err error) {
+
+	// Start the span
+	ctx, span := c.startSpan(ctx, "GetEncryptionKeypair")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// Make sure we have an auth token
+	if len(authToken) == 0 {
+		return nil, fmt.Errorf("missing auth token")
+	}
+
+	// Create the ephemeral key for the response
+	ephemeral, err := bec.NewPrivateKey(bec.S256())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ephemeral key: %w", err)
+	}
+	params := &EncryptionKeypairRequest{
+		EncryptionPublicKey: hex.EncodeToString(ephemeral.PubKey().SerialiseCompressed()),
+	}
+
+	// Get the signed request
+	var signed *signedRequest
+	if signed, err = c.getSignedRequest(
+		http.MethodGet,
+		endpointGetEncryptionKeypair,
+		authToken,
+		params,
+		currentISOTimestamp(),
+	); err != nil {
+		return nil, fmt.Errorf("error creating signed request: %w", err)
+	}
+
+	// Convert into bytes
+	var paramsBytes []byte
+	if paramsBytes, err = json.Marshal(params); err != nil {
+		return nil, err
+	}
+
+	// Make the HTTP request
+	response := httpRequest(
+		ctx,
+		c,
+		&httpPayload{
+			Data:           paramsBytes,
+			ExpectedStatus: http.StatusOK,
+			Method:         signed.Method,
+			URL:            signed.URI,
+		},
+		signed,
+	)
+
+	// Error in request?
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	// Unmarshal the encrypted key pair
+	encrypted := new(encryptionKeypairResponse)
+	if err = json.Unmarshal(response.BodyContents, &encrypted); err != nil {
+		return nil, fmt.Errorf("failed unmarshal: %w", err)
+	} else if encrypted == nil || encrypted.EncryptedPrivateKeyHex == "" ||
+		encrypted.EncryptedPublicKeyHex == "" {
+		return nil, fmt.Errorf("failed to get encryption keypair")
+	}
+
+	// Decrypt the key pair
+	return decryptKeypair(ephemeral, encrypted)
+}
+
+// Encrypt will encrypt the data for the key pair (ECIES)
+func (e *EncryptionKeypair) Encrypt(data []byte) ([]byte, error) {
+	if e.PublicKey == nil {
+		return nil, fmt.Errorf("missing public key")
+	}
+	return eciesEncrypt(e.PublicKey, data)
+}
+
+// Decrypt will decrypt data encrypted for the key pair (ECIES)
+func (e *EncryptionKeypair) Decrypt(data []byte) ([]byte, error) {
+	if e.PrivateKey == nil {
+		return nil, fmt.Errorf("missing private key")
+	}
+	return eciesDecrypt(e.PrivateKey, data)
+}
+
+// decryptKeypair will decrypt the key pair using the ephemeral key
+func decryptKeypair(ephemeral *bec.PrivateKey,
+	encrypted *encryptionKeypairResponse) (*EncryptionKeypair, error) {
+
+	// Decrypt the private key
+	privateKeyHex, err := decryptHex(ephemeral, encrypted.EncryptedPrivateKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	var privateKeyBytes []byte
+	if privateKeyBytes, err = hex.DecodeString(string(privateKeyHex)); err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	privateKey, derivedPublicKey := bec.PrivKeyFromBytes(bec.S256(), privateKeyBytes)
+
+	// Decrypt the public key
+	var publicKeyHex []byte
+	if publicKeyHex, err = decryptHex(ephemeral, encrypted.EncryptedPublicKeyHex); err != nil {
+		return nil, fmt.Errorf("failed to decrypt public key: %w", err)
+	}
+	var publicKeyBytes []byte
+	if publicKeyBytes, err = hex.DecodeString(string(publicKeyHex)); err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	var publicKey *bec.PublicKey
+	if publicKey, err = bec.ParsePubKey(publicKeyBytes, bec.S256()); err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	// Make sure the keys belong together
+	if !publicKey.IsEqual(derivedPublicKey) {
+		return nil, fmt.Errorf("public key does not match private key")
+	}
+
+	return &EncryptionKeypair{PrivateKey: privateKey, PublicKey: publicKey}, nil
+}
+
+// decryptHex will decode and decrypt the hex encoded data
+func decryptHex(privateKey *bec.PrivateKey, data string) ([]byte, error) {
+	encrypted, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	return eciesDecrypt(privateKey, encrypted)
+}
@@ -0,0 +1,190 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockHTTPGetEncryptionKeypair for mocking requests
+type mockHTTPGetEncryptionKeypair struct {
+	userKey *bec.PrivateKey
+}
+
+// Do is a mock http request
+func (m *mockHTTPGetEncryptionKeypair) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	// Beta
+	if req.URL.String() == environments[EnvironmentBeta].APIURL+endpointGetEncryptionKeypair {
+
+		// Encrypt the user's key pair for the ephemeral key
+		params := new(EncryptionKeypairRequest)
+		if err := json.NewDecoder(req.Body).Decode(params); err != nil {
+			return resp, err
+		}
+		ephemeralBytes, err := hex.DecodeString(params.EncryptionPublicKey)
+		if err != nil {
+			return resp, err
+		}
+		var ephemeral *bec.PublicKey
+		if ephemeral, err = bec.ParsePubKey(ephemeralBytes, bec.S256()); err != nil {
+			return resp, err
+		}
+		var encryptedPrivateKey, encryptedPublicKey []byte
+		if encryptedPrivateKey, err = eciesEncrypt(
+			ephemeral, []byte(hex.EncodeToString(m.userKey.Serialise())),
+		); err != nil {
+			return resp, err
+		}
+		if encryptedPublicKey, err = eciesEncrypt(
+			ephemeral, []byte(hex.EncodeToString(m.userKey.PubKey().SerialiseCompressed())),
+		); err != nil {
+			return resp, err
+		}
+
+		body, _ := json.Marshal(&encryptionKeypairResponse{
+			EncryptedPrivateKeyHex: hex.EncodeToString(encryptedPrivateKey),
+			EncryptedPublicKeyHex:  hex.EncodeToString(encryptedPublicKey),
+		})
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	}
+
+	// Default is valid
+	return resp, nil
+}
+
+// mockHTTPInvalidEncryptionKeypairData for mocking requests
+type mockHTTPInvalidEncryptionKeypairData struct{}
+
+// Do is a mock http request
+func (m *mockHTTPInvalidEncryptionKeypairData) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	resp.StatusCode = http.StatusOK
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"encryptedPublicKeyHex":"00","encryptedPrivateKeyHex":"00"}`)))
+
+	// Default is valid
+	return resp, nil
+}
+
+func TestClient_GetEncryptionKeypair(t *testing.T) {
+	t.Parallel()
+
+	userKey, err := bec.NewPrivateKey(bec.S256())
+	assert.NoError(t, err)
+
+	t.Run("missing auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetEncryptionKeypair{userKey: userKey}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		keypair, err := client.GetEncryptionKeypair(context.Background(), "")
+		assert.Error(t, err)
+		assert.Nil(t, keypair)
+	})
+
+	t.Run("invalid auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetEncryptionKeypair{userKey: userKey}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		keypair, err := client.GetEncryptionKeypair(context.Background(), "0")
+		assert.Error(t, err)
+		assert.Nil(t, keypair)
+	})
+
+	t.Run("valid keypair", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetEncryptionKeypair{userKey: userKey}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		keypair, err := client.GetEncryptionKeypair(context.Background(), "000000")
+		assert.NoError(t, err)
+		assert.NotNil(t, keypair)
+		assert.True(t, keypair.PublicKey.IsEqual(userKey.PubKey()))
+		assert.Equal(t, userKey.D, keypair.PrivateKey.D)
+
+		// Round trip some data
+		var encrypted, decrypted []byte
+		encrypted, err = keypair.Encrypt([]byte("secret data"))
+		assert.NoError(t, err)
+		decrypted, err = keypair.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret data", string(decrypted))
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		client := newTestClient(&mockHTTPBadRequest{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		keypair, err := client.GetEncryptionKeypair(context.Background(), "000000")
+		assert.Error(t, err)
+		assert.Nil(t, keypair)
+	})
+
+	t.Run("invalid keypair data", func(t *testing.T) {
+		client := newTestClient(&mockHTTPInvalidEncryptionKeypairData{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		keypair, err := client.GetEncryptionKeypair(context.Background(), "000000")
+		assert.Error(t, err)
+		assert.Nil(t, keypair)
+	})
+}
+
+func TestEncryptionKeypair_Encrypt(t *testing.T) {
+	t.Parallel()
+
+	privateKey, err := bec.NewPrivateKey(bec.S256())
+	assert.NoError(t, err)
+
+	t.Run("encrypt with public key only", func(t *testing.T) {
+		keypair := &EncryptionKeypair{PublicKey: privateKey.PubKey()}
+		encrypted, err := keypair.Encrypt([]byte("hello world"))
+		assert.NoError(t, err)
+		assert.Equal(t, eciesMagic, string(encrypted[:4]))
+
+		// Can't decrypt without the private key
+		var decrypted []byte
+		decrypted, err = keypair.Decrypt(encrypted)
+		assert.Error(t, err)
+		assert.Nil(t, decrypted)
+
+		keypair.PrivateKey = privateKey
+		decrypted, err = keypair.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello world", string(decrypted))
+	})
+
+	t.Run("missing public key", func(t *testing.T) {
+		keypair := &EncryptionKeypair{}
+		encrypted, err := keypair.Encrypt([]byte("hello world"))
+		assert.Error(t, err)
+		assert.Nil(t, encrypted)
+	})
+
+	t.Run("wrong private key", func(t *testing.T) {
+		otherKey, err := bec.NewPrivateKey(bec.S256())
+		assert.NoError(t, err)
+
+		keypair := &EncryptionKeypair{PublicKey: privateKey.PubKey(), PrivateKey: otherKey}
+		var encrypted, decrypted []byte
+		encrypted, err = keypair.Encrypt([]byte("hello world"))
+		assert.NoError(t, err)
+		decrypted, err = keypair.Decrypt(encrypted)
+		assert.Error(t, err)
+		assert.Nil(t, decrypted)
+	})
+}
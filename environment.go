@@ -0,0 +1,88 @@
+package handcash
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// environmentsMu protects the environments (custom environments can be registered at any time)
+var environmentsMu sync.RWMutex
+
+// RegisterEnvironment will add a custom environment (IE: a local stand-in or a staging proxy)
+//
+// The environment can then be used by name with NewClient() and App. Registering an
+// existing custom name replaces that environment for clients created afterwards, but the
+// built-in environments (production, beta and iae) cannot be replaced.
+func RegisterEnvironment(name string, environment *Environment) error {
+	if len(name) == 0 {
+		return fmt.Errorf("missing environment name")
+	} else if isBuiltInEnvironment(name) {
+		return fmt.Errorf("cannot replace the built-in environment: %q", name)
+	}
+	registered, err := newEnvironment(environment)
+	if err != nil {
+		return err
+	}
+	registered.Environment = name
+
+	environmentsMu.Lock()
+	defer environmentsMu.Unlock()
+	environments[name] = registered
+	return nil
+}
+
+// GetEnvironment will return a copy of the environment (an error if the name is unknown)
+func GetEnvironment(name string) (*Environment, error) {
+	environmentsMu.RLock()
+	defer environmentsMu.RUnlock()
+
+	environment, ok := environments[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown environment: %q", name)
+	}
+	environmentCopy := *environment
+	return &environmentCopy, nil
+}
+
+// isBuiltInEnvironment will return true for the HandCash environments
+func isBuiltInEnvironment(name string) bool {
+	return name == EnvironmentBeta || name == EnvironmentIAE || name == EnvironmentProduction
+}
+
+// NewClientWithEnvironment creates a new client for requests to the given environment
+//
+// The environment does not need to be registered
+func NewClientWithEnvironment(options *ClientOptions, customHTTPClient *http.Client,
+	environment *Environment) (*Client, error) {
+
+	clientEnvironment, err := newEnvironment(environment)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(options, customHTTPClient, clientEnvironment), nil
+}
+
+// newEnvironment will return a validated copy of the environment (without trailing slashes)
+func newEnvironment(environment *Environment) (*Environment, error) {
+	if environment == nil {
+		return nil, fmt.Errorf("missing environment")
+	}
+	environmentCopy := *environment
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{
+		{name: "api url", value: &environmentCopy.APIURL},
+		{name: "client url", value: &environmentCopy.ClientURL},
+	} {
+		u, err := url.Parse(*field.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return nil, fmt.Errorf("invalid environment %s: %q", field.name, *field.value)
+		}
+		*field.value = strings.TrimRight(*field.value, "/")
+	}
+	return &environmentCopy, nil
+}
@@ -0,0 +1,117 @@
+package handcash
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRegisterEnvironment is not parallel (other tests read the environments directly)
+func TestRegisterEnvironment(t *testing.T) {
+
+	t.Run("invalid environments", func(t *testing.T) {
+		assert.Error(t, RegisterEnvironment("", &Environment{APIURL: "http://localhost", ClientURL: "http://localhost"}))
+		assert.Error(t, RegisterEnvironment("local", nil))
+		assert.Error(t, RegisterEnvironment("local", &Environment{ClientURL: "http://localhost"}))
+		assert.Error(t, RegisterEnvironment("local", &Environment{APIURL: "localhost", ClientURL: "http://localhost"}))
+		assert.Error(t, RegisterEnvironment("local", &Environment{APIURL: "http://localhost", ClientURL: "ftp://localhost"}))
+
+		_, err := GetEnvironment("local")
+		assert.Error(t, err)
+	})
+
+	t.Run("built-in environments cannot be replaced", func(t *testing.T) {
+		for _, name := range []string{EnvironmentBeta, EnvironmentIAE, EnvironmentProduction} {
+			assert.Error(t, RegisterEnvironment(name, &Environment{
+				APIURL: "http://localhost", ClientURL: "http://localhost",
+			}))
+			environment, err := GetEnvironment(name)
+			require.NoError(t, err)
+			assert.NotEqual(t, "http://localhost", environment.APIURL)
+		}
+	})
+
+	t.Run("registered environment", func(t *testing.T) {
+		require.NoError(t, RegisterEnvironment("staging", &Environment{
+			APIURL:    "https://staging-cloud.example.com/",
+			ClientURL: "https://staging-app.example.com",
+		}))
+
+		environment, err := GetEnvironment("staging")
+		require.NoError(t, err)
+		assert.Equal(t, "https://staging-cloud.example.com", environment.APIURL)
+		assert.Equal(t, "https://staging-app.example.com", environment.ClientURL)
+		assert.Equal(t, "staging", environment.Environment)
+
+		client := NewClient(nil, nil, "staging")
+		assert.Equal(t, environment, client.Environment)
+
+		var appClient *Client
+		appClient, err = NewAppClient(&App{AppID: "app", AppSecret: "secret", Environment: "staging"}, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, environment, appClient.Environment)
+	})
+}
+
+func TestGetEnvironment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("known environment", func(t *testing.T) {
+		environment, err := GetEnvironment(EnvironmentBeta)
+		require.NoError(t, err)
+		assert.Equal(t, EnvironmentBeta, environment.Environment)
+
+		// The environment is a copy
+		environment.APIURL = "https://example.com"
+		environment, err = GetEnvironment(EnvironmentBeta)
+		require.NoError(t, err)
+		assert.Equal(t, "https://beta-cloud.handcash.io", environment.APIURL)
+	})
+
+	t.Run("unknown environment", func(t *testing.T) {
+		environment, err := GetEnvironment("bta")
+		assert.EqualError(t, err, `unknown environment: "bta"`)
+		assert.Nil(t, environment)
+	})
+}
+
+func TestNewClientWithEnvironment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid environment", func(t *testing.T) {
+		client, err := NewClientWithEnvironment(nil, nil, nil)
+		assert.Error(t, err)
+		assert.Nil(t, client)
+
+		client, err = NewClientWithEnvironment(nil, nil, &Environment{APIURL: "not a url"})
+		assert.Error(t, err)
+		assert.Nil(t, client)
+	})
+
+	t.Run("requests use the environment", func(t *testing.T) {
+		var path string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			path = req.URL.Path
+			_, _ = w.Write([]byte(`{"publicProfile":{"id":"1234567","handle":"MisterZ"}}`))
+		}))
+		defer server.Close()
+
+		client, err := NewClientWithEnvironment(nil, http.DefaultClient, &Environment{
+			APIURL:      server.URL + "/",
+			ClientURL:   server.URL,
+			Environment: "local",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, server.URL, client.Environment.APIURL)
+
+		var profile *Profile
+		profile, err = client.GetProfile(context.Background(), "000000")
+		require.NoError(t, err)
+		assert.NotNil(t, profile)
+		assert.Equal(t, endpointProfileCurrent, path)
+	})
+}
@@ -0,0 +1,51 @@
+package handcash
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when the HandCash Connect API responds with an unexpected status
+type APIError struct {
+	Body       []byte `json:"body"`        // Body is the raw body response
+	Endpoint   string `json:"endpoint"`    // Endpoint is the API endpoint used (IE: /v1/connect/wallet/pay)
+	Message    string `json:"message"`     // Message is the error message from HandCash (if any)
+	Method     string `json:"method"`      // Method is the HTTP method used
+	StatusCode int    `json:"status_code"` // StatusCode is the code from the response
+}
+
+// Error will return the error message
+func (e *APIError) Error() string {
+	if len(e.Message) > 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("request failed with status code: %d", e.StatusCode)
+}
+
+// IsUnauthorized will return true if the error is an API error with a 401 status code
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized)
+}
+
+// IsRateLimited will return true if the error is an API error with a 429 status code
+func IsRateLimited(err error) bool {
+	return hasStatusCode(err, http.StatusTooManyRequests)
+}
+
+// IsInsufficientBalance will return true if the error is an API error for a
+// payment that exceeds the user's spendable balance
+func IsInsufficientBalance(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return strings.Contains(strings.ToLower(apiErr.Message), "insufficient")
+}
+
+// hasStatusCode will return true if the error is an API error with the given status code
+func hasStatusCode(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
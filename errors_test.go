@@ -0,0 +1,124 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockHTTPErrorStatus for mocking requests
+type mockHTTPErrorStatus struct {
+	body       string
+	statusCode int
+}
+
+// Do is a mock http request
+func (m *mockHTTPErrorStatus) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	resp.StatusCode = m.statusCode
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(m.body)))
+
+	// Default is valid
+	return resp, nil
+}
+
+func TestAPIError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error with message", func(t *testing.T) {
+		client := newTestClient(&mockHTTPBadRequest{}, EnvironmentBeta)
+		profile, err := client.GetProfile(context.Background(), "000000")
+		assert.Nil(t, profile)
+		assert.Error(t, err)
+		assert.Equal(t, "bad request", err.Error())
+
+		var apiErr *APIError
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(t, "bad request", apiErr.Message)
+		assert.Equal(t, endpointProfileCurrent, apiErr.Endpoint)
+		assert.Equal(t, http.MethodGet, apiErr.Method)
+		assert.Equal(t, `{"Message":"bad request"}`, string(apiErr.Body))
+	})
+
+	t.Run("error without body", func(t *testing.T) {
+		client := newTestClient(&mockHTTPErrorStatus{statusCode: http.StatusBadGateway}, EnvironmentBeta)
+		profile, err := client.GetProfile(context.Background(), "000000")
+		assert.Nil(t, profile)
+		assert.Error(t, err)
+		assert.Equal(t, "request failed with status code: 502", err.Error())
+	})
+
+	t.Run("error with invalid json body", func(t *testing.T) {
+		client := newTestClient(&mockHTTPErrorStatus{
+			body: "<html>bad gateway</html>", statusCode: http.StatusBadGateway,
+		}, EnvironmentBeta)
+		profile, err := client.GetProfile(context.Background(), "000000")
+		assert.Nil(t, profile)
+		assert.Error(t, err)
+
+		var apiErr *APIError
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+		assert.Equal(t, "<html>bad gateway</html>", string(apiErr.Body))
+	})
+
+	t.Run("wrapped error", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", &APIError{StatusCode: http.StatusUnauthorized})
+		var apiErr *APIError
+		assert.True(t, errors.As(err, &apiErr))
+		assert.True(t, IsUnauthorized(err))
+	})
+}
+
+func TestIsUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(&mockHTTPErrorStatus{
+		body: `{"message":"Invalid authentication"}`, statusCode: http.StatusUnauthorized,
+	}, EnvironmentBeta)
+	_, err := client.GetProfile(context.Background(), "000000")
+	assert.True(t, IsUnauthorized(err))
+	assert.False(t, IsRateLimited(err))
+	assert.False(t, IsInsufficientBalance(err))
+	assert.False(t, IsUnauthorized(fmt.Errorf("some error")))
+	assert.False(t, IsUnauthorized(nil))
+}
+
+func TestIsRateLimited(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(&mockHTTPErrorStatus{
+		body: `{"message":"Too many requests"}`, statusCode: http.StatusTooManyRequests,
+	}, EnvironmentBeta)
+	_, err := client.GetProfile(context.Background(), "000000")
+	assert.True(t, IsRateLimited(err))
+	assert.False(t, IsUnauthorized(err))
+	assert.False(t, IsRateLimited(fmt.Errorf("some error")))
+}
+
+func TestIsInsufficientBalance(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(&mockHTTPErrorStatus{
+		body: `{"message":"Insufficient balance"}`, statusCode: http.StatusBadRequest,
+	}, EnvironmentBeta)
+	_, err := client.Pay(context.Background(), "000000", &PayParameters{
+		Receivers: []*Payment{{Amount: 0.01, CurrencyCode: CurrencyUSD, To: "mrz@moneybutton.com"}},
+	})
+	assert.True(t, IsInsufficientBalance(err))
+	assert.False(t, IsUnauthorized(err))
+	assert.False(t, IsInsufficientBalance(fmt.Errorf("insufficient balance")))
+}
@@ -0,0 +1,139 @@
+package handcash
+
+import (
+	"context"
+	"fmt"
+)
+
+// The methods below are variants of the client methods taking a user ID instead of an
+// auth token. The auth token is resolved from the client token store (ClientOptions.TokenStore).
+
+// userAuthToken will return the auth token of the user from the token store
+func (c *Client) userAuthToken(ctx context.Context, userID string) (string, error) {
+	if len(userID) == 0 {
+		return "", fmt.Errorf("missing user id")
+	}
+	authToken, err := c.tokenStore.Get(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get auth token for user %s: %w", userID, err)
+	}
+	return authToken, nil
+}
+
+// SaveRedirectionCallback will store the auth token from the app's callback URL for the user
+//
+// If expectedState is set, the state query parameter must match it (see ParseRedirectionCallback)
+func (c *Client) SaveRedirectionCallback(ctx context.Context, userID, callbackURL, expectedState string) error {
+	authToken, err := ParseRedirectionCallback(callbackURL, expectedState)
+	if err != nil {
+		return err
+	}
+	return c.tokenStore.Set(ctx, userID, authToken)
+}
+
+// DeleteUserToken will remove the auth token of the user from the token store
+func (c *Client) DeleteUserToken(ctx context.Context, userID string) error {
+	return c.tokenStore.Delete(ctx, userID)
+}
+
+// GetProfileForUser will get the profile of the user (see GetProfile)
+func (c *Client) GetProfileForUser(ctx context.Context, userID string) (*Profile, error) {
+	authToken, err := c.userAuthToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetProfile(ctx, authToken)
+}
+
+// GetPublicProfilesByHandleForUser will get the public profiles of the handles (see GetPublicProfilesByHandle)
+func (c *Client) GetPublicProfilesByHandleForUser(ctx context.Context, userID string,
+	handles []string) ([]*PublicProfile, error) {
+
+	authToken, err := c.userAuthToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetPublicProfilesByHandle(ctx, authToken, handles)
+}
+
+// GetFriendsForUser will get the friends of the user (see GetFriends)
+func (c *Client) GetFriendsForUser(ctx context.Context, userID string) ([]*PublicProfile, error) {
+	authToken, err := c.userAuthToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetFriends(ctx, authToken)
+}
+
+// GetPermissionsForUser will get the permissions granted by the user (see GetPermissions)
+func (c *Client) GetPermissionsForUser(ctx context.Context, userID string) (PermissionSet, error) {
+	authToken, err := c.userAuthToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetPermissions(ctx, authToken)
+}
+
+// GetEncryptionKeypairForUser will get the encryption keypair of the user (see GetEncryptionKeypair)
+func (c *Client) GetEncryptionKeypairForUser(ctx context.Context, userID string) (*EncryptionKeypair, error) {
+	authToken, err := c.userAuthToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetEncryptionKeypair(ctx, authToken)
+}
+
+// SignDataForUser will sign the value with the key of the user (see SignData)
+func (c *Client) SignDataForUser(ctx context.Context, userID, value string,
+	format DataFormat) (*SignDataResponse, error) {
+
+	authToken, err := c.userAuthToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.SignData(ctx, authToken, value, format)
+}
+
+// GetSpendableBalanceForUser will get the spendable balance of the user (see GetSpendableBalance)
+func (c *Client) GetSpendableBalanceForUser(ctx context.Context, userID string,
+	currencyCode CurrencyCode) (*SpendableBalanceResponse, error) {
+
+	authToken, err := c.userAuthToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetSpendableBalance(ctx, authToken, currencyCode)
+}
+
+// PayForUser will make a payment from the user (see Pay)
+func (c *Client) PayForUser(ctx context.Context, userID string,
+	payParams *PayParameters) (*PaymentResponse, error) {
+
+	authToken, err := c.userAuthToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.Pay(ctx, authToken, payParams)
+}
+
+// PayBatchForUser will pay all the receivers from the user (see PayBatch)
+func (c *Client) PayBatchForUser(ctx context.Context, userID string, receivers []*Payment,
+	opts *PayBatchOptions) (*PayBatchReport, error) {
+
+	authToken, err := c.userAuthToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.PayBatch(ctx, authToken, receivers, opts)
+}
+
+// GetPaymentForUser will get a payment of the user (see GetPayment)
+func (c *Client) GetPaymentForUser(ctx context.Context, userID,
+	transactionID string) (*PaymentResponse, error) {
+
+	authToken, err := c.userAuthToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetPayment(ctx, authToken, transactionID)
+}
@@ -0,0 +1,90 @@
+package handcash
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestUserClient returns a client with a token store holding user-1
+func newTestUserClient(t *testing.T, httpClient httpInterface) *Client {
+	store := NewMemoryTokenStore()
+	require.NoError(t, store.Set(context.Background(), "user-1", testUserAuthToken))
+
+	client, err := New(WithEnvironment(EnvironmentBeta), WithTokenStore(store))
+	require.NoError(t, err)
+	client.httpClient = httpClient
+	return client
+}
+
+func TestClient_ForUser(t *testing.T) {
+	t.Parallel()
+
+	t.Run("token is resolved from the store", func(t *testing.T) {
+		client := newTestUserClient(t, &mockHTTPGetProfile{})
+		profile, err := client.GetProfileForUser(context.Background(), "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, "MisterZ", profile.PublicProfile.Handle)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		client := newTestUserClient(t, &mockHTTPGetProfile{})
+		profile, err := client.GetProfileForUser(context.Background(), "user-2")
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		assert.Nil(t, profile)
+
+		_, err = client.GetProfileForUser(context.Background(), "")
+		assert.Error(t, err)
+	})
+
+	t.Run("all variants check the user", func(t *testing.T) {
+		client := newTestUserClient(t, &mockHTTPGetProfile{})
+		ctx := context.Background()
+
+		_, err := client.GetPublicProfilesByHandleForUser(ctx, "user-2", []string{"MisterZ"})
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		_, err = client.GetFriendsForUser(ctx, "user-2")
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		_, err = client.GetPermissionsForUser(ctx, "user-2")
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		_, err = client.GetEncryptionKeypairForUser(ctx, "user-2")
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		_, err = client.SignDataForUser(ctx, "user-2", "value", DataFormatUTF8)
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		_, err = client.GetSpendableBalanceForUser(ctx, "user-2", CurrencyUSD)
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		_, err = client.PayForUser(ctx, "user-2", newTestPayParameters(""))
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		_, err = client.PayBatchForUser(ctx, "user-2", nil, nil)
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		_, err = client.GetPaymentForUser(ctx, "user-2", "tx-1")
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+	})
+
+	t.Run("save and delete the token", func(t *testing.T) {
+		client := newTestUserClient(t, &mockHTTPGetProfile{})
+		ctx := context.Background()
+
+		err := client.SaveRedirectionCallback(ctx, "user-2",
+			"https://example.com/callback?authToken="+testOtherUserAuthToken+"&state=abc", "abc")
+		require.NoError(t, err)
+		_, err = client.GetProfileForUser(ctx, "user-2")
+		require.NoError(t, err)
+
+		err = client.SaveRedirectionCallback(ctx, "user-3",
+			"https://example.com/callback?authToken="+testOtherUserAuthToken+"&state=xyz", "abc")
+		assert.Error(t, err)
+
+		require.NoError(t, client.DeleteUserToken(ctx, "user-2"))
+		_, err = client.GetProfileForUser(ctx, "user-2")
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+	})
+
+	t.Run("default token store", func(t *testing.T) {
+		client := NewClient(nil, http.DefaultClient, EnvironmentBeta)
+		assert.NotNil(t, client.tokenStore)
+	})
+}
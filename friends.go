@@ -0,0 +1,79 @@
+package handcash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+/*
+{
+  "items": [
+    {
+      "id": "5f70dd4beea0c1002f6cbb2e",
+      "handle": "rjseibane",
+      "paymail": "rjseibane@beta.handcash.io",
+      "displayName": "Rafa",
+      "avatarUrl": "https://beta-cloud.handcash.io/users/profilePicture/rjseibane",
+      "localCurrencyCode": "EUR"
+    }
+  ]
+}
+*/
+
+// GetFriends will get the list of friends for the associated auth token
+//
+// Specs: https://github.com/HandCash/handcash-connect-sdk-js/blob/master/src/profile/index.js
+func (c *Client) GetFriends(ctx context.Context, authToken string) (_ []*PublicProfile, err error) {
+
+	// Start the span
+	ctx, span := c.startSpan(ctx, "GetFriends")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// Make sure we have an auth token
+	if len(authToken) == 0 {
+		return nil, fmt.Errorf("missing auth token")
+	}
+
+	// Get the signed request
+	signed, err := c.getSignedRequest(
+		http.MethodGet,
+		endpointGetFriends,
+		authToken,
+		&requestBody{authToken: authToken},
+		currentISOTimestamp(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating signed request: %w", err)
+	}
+
+	// Make the HTTP request
+	response := httpRequest(
+		ctx,
+		c,
+		&httpPayload{
+			Data:           []byte(emptyBody),
+			ExpectedStatus: http.StatusOK,
+			Method:         signed.Method,
+			URL:            signed.URI,
+		},
+		signed,
+	)
+
+	// Error in request?
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	// Unmarshal into the friends list
+	friends := new(publicProfilesResponse)
+	if err = json.Unmarshal(response.BodyContents, &friends); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal: %w", err)
+	} else if friends == nil || friends.Items == nil {
+		return nil, fmt.Errorf("failed to find friends")
+	}
+	return friends.Items, nil
+}
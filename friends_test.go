@@ -0,0 +1,119 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockHTTPGetFriends for mocking requests
+type mockHTTPGetFriends struct{}
+
+// Do is a mock http request
+func (m *mockHTTPGetFriends) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	// Beta
+	if req.URL.String() == environments[EnvironmentBeta].APIURL+endpointGetFriends {
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"items":[{"id":"1234567","handle":"rjseibane","paymail":"rjseibane@beta.handcash.io","displayName":"Rafa","avatarUrl":"https://beta-cloud.handcash.io/users/profilePicture/rjseibane","localCurrencyCode":"EUR"},{"id":"7654321","handle":"MisterZ","paymail":"MisterZ@beta.handcash.io","displayName":"","avatarUrl":"https://beta-cloud.handcash.io/users/profilePicture/MisterZ","localCurrencyCode":"USD"}]}`)))
+	}
+
+	// Production
+	if req.URL.String() == environments[EnvironmentProduction].APIURL+endpointGetFriends {
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"items":[]}`)))
+	}
+
+	// Default is valid
+	return resp, nil
+}
+
+// mockHTTPInvalidFriendsData for mocking requests
+type mockHTTPInvalidFriendsData struct{}
+
+// Do is a mock http request
+func (m *mockHTTPInvalidFriendsData) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	resp.StatusCode = http.StatusOK
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"invalid":"friends"}`)))
+
+	// Default is valid
+	return resp, nil
+}
+
+func TestClient_GetFriends(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetFriends{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		friends, err := client.GetFriends(context.Background(), "")
+		assert.Error(t, err)
+		assert.Nil(t, friends)
+	})
+
+	t.Run("invalid auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetFriends{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		friends, err := client.GetFriends(context.Background(), "0")
+		assert.Error(t, err)
+		assert.Nil(t, friends)
+	})
+
+	t.Run("valid auth token (beta)", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetFriends{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		friends, err := client.GetFriends(context.Background(), "000000")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(friends))
+		assert.Equal(t, "1234567", friends[0].ID)
+		assert.Equal(t, "rjseibane", friends[0].Handle)
+		assert.Equal(t, "rjseibane@beta.handcash.io", friends[0].Paymail)
+		assert.Equal(t, "Rafa", friends[0].DisplayName)
+		assert.Equal(t, CurrencyEUR, friends[0].LocalCurrencyCode)
+		assert.Equal(t, "https://beta-cloud.handcash.io/users/profilePicture/rjseibane", friends[0].AvatarURL)
+		assert.Equal(t, "MisterZ", friends[1].Handle)
+	})
+
+	t.Run("no friends (production)", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetFriends{}, EnvironmentProduction)
+		assert.NotNil(t, client)
+		friends, err := client.GetFriends(context.Background(), "000000")
+		assert.NoError(t, err)
+		assert.NotNil(t, friends)
+		assert.Equal(t, 0, len(friends))
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		client := newTestClient(&mockHTTPBadRequest{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		friends, err := client.GetFriends(context.Background(), "000000")
+		assert.Error(t, err)
+		assert.Nil(t, friends)
+	})
+
+	t.Run("invalid friends data", func(t *testing.T) {
+		client := newTestClient(&mockHTTPInvalidFriendsData{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		friends, err := client.GetFriends(context.Background(), "000000")
+		assert.Error(t, err)
+		assert.Nil(t, friends)
+	})
+}
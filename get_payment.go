@@ -35,7 +35,13 @@ import (
 //
 // Specs: https://github.com/HandCash/handcash-connect-sdk-js/blob/master/src/api/http_request_factory.js
 func (c *Client) GetPayment(ctx context.Context, authToken,
-	transactionID string) (*PaymentResponse, error) {
+	transactionID string) (_ *PaymentResponse, err error) {
+
+	// Start the span
+	ctx, span := c.startSpan(ctx, "GetPayment")
+	defer func() {
+		endSpan(span, err)
+	}()
 
 	// Make sure we have an auth token
 	if len(authToken) == 0 {
@@ -89,5 +95,6 @@ func (c *Client) GetPayment(ctx context.Context, authToken,
 	} else if paymentResponse == nil || paymentResponse.TransactionID == "" {
 		return nil, fmt.Errorf("failed to find payment")
 	}
+	setPaymentAttributes(span, paymentResponse)
 	return paymentResponse, nil
 }
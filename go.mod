@@ -1,22 +1,30 @@
 module github.com/tonicpow/go-handcash-connect
 
-go 1.18
+go 1.23.0
 
 require (
 	github.com/bitcoinschema/go-bitcoin/v2 v2.0.5
 	github.com/bitcoinsv/bsvd v0.0.0-20190609155523-4c29707f7173
 	github.com/gojektech/heimdall/v6 v6.1.0
 	github.com/libsv/go-bk v0.1.6
+	github.com/libsv/go-bt/v2 v2.2.2
 	github.com/stretchr/testify v1.10.0
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/trace v1.14.0
 )
 
+// Only used by the tests (tracetest records the spans)
+require go.opentelemetry.io/otel/sdk v1.14.0
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/gojektech/valkyrie v0.0.0-20190210220504-8f62c1e7ba45 // indirect
-	github.com/libsv/go-bt/v2 v2.2.2 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
 	golang.org/x/crypto v0.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,360 @@
+// Package handcashtest provides a local fake of the HandCash Connect API for integration tests
+//
+// The fake serves the profile, spendable balance, pay and payment endpoints, verifies the
+// signed oAuth headers on every request, keeps per-token balances in memory and lets tests
+// script failures.
+package handcashtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/tonicpow/go-handcash-connect"
+)
+
+// Paths served by the fake server
+const (
+	PathPay              = "/v1/connect/wallet/pay"
+	PathPayment          = "/v1/connect/wallet/payment"
+	PathProfile          = "/v1/connect/profile/currentUserProfile"
+	PathSpendableBalance = "/v1/connect/wallet/spendableBalance"
+)
+
+const (
+
+	// EnvironmentTest is the environment name of the fake server
+	EnvironmentTest = "test"
+
+	// DefaultExchangeRate is the default fiat price of one BSV
+	DefaultExchangeRate = 50.0
+
+	// DefaultMaxSkew is the default max age of a signed request
+	DefaultMaxSkew = 5 * time.Minute
+
+	// satoshisPerBitcoin is the number of satoshis in one BSV
+	satoshisPerBitcoin = 100000000
+)
+
+// Account is a user of the fake server
+type Account struct {
+	Profile        *handcash.Profile
+	SatoshiBalance uint64
+}
+
+// failure is a scripted error response
+type failure struct {
+	message    string
+	statusCode int
+}
+
+// Server is a fake HandCash Connect API
+type Server struct {
+	*httptest.Server
+
+	ExchangeRate float64       // Fiat price of one BSV (used for all currencies)
+	MaxSkew      time.Duration // Stale and replayed requests are rejected (must be greater than zero)
+	SatoshiFees  uint64        // Fee charged on every payment
+
+	accounts map[string]*Account                  // Keyed by the public key of the auth token
+	failures map[string][]*failure                // Keyed by the path
+	mu       sync.Mutex                           // Protects all the fields
+	payments map[string]*handcash.PaymentResponse // Keyed by the transaction id
+	replays  *handcash.MemoryReplayCache          // Signed requests seen by this server
+}
+
+// NewServer will start a new fake server
+//
+// Use Environment() with handcash.NewClientWithEnvironment() to point a client at the server
+func NewServer() *Server {
+	s := &Server{
+		ExchangeRate: DefaultExchangeRate,
+		MaxSkew:      DefaultMaxSkew,
+		accounts:     make(map[string]*Account),
+		failures:     make(map[string][]*failure),
+		payments:     make(map[string]*handcash.PaymentResponse),
+		replays:      handcash.NewMemoryReplayCache(0),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathPay, s.handle(http.MethodPost, s.pay))
+	mux.HandleFunc(PathPayment, s.handle(http.MethodGet, s.payment))
+	mux.HandleFunc(PathProfile, s.handle(http.MethodGet, s.profile))
+	mux.HandleFunc(PathSpendableBalance, s.handle(http.MethodGet, s.spendableBalance))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Environment will return the environment for the fake server
+func (s *Server) Environment() *handcash.Environment {
+	return &handcash.Environment{
+		APIURL:      s.URL,
+		ClientURL:   s.URL,
+		Environment: EnvironmentTest,
+	}
+}
+
+// AddAccount will add a user for the given auth token
+func (s *Server) AddAccount(authToken string, profile *handcash.Profile, satoshiBalance uint64) error {
+	publicKey, err := publicKeyFromToken(authToken)
+	if err != nil {
+		return err
+	} else if profile == nil {
+		return fmt.Errorf("missing profile")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[publicKey] = &Account{Profile: profile, SatoshiBalance: satoshiBalance}
+	return nil
+}
+
+// Balance will return the satoshi balance for the given auth token
+func (s *Server) Balance(authToken string) uint64 {
+	publicKey, err := publicKeyFromToken(authToken)
+	if err != nil {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if account, ok := s.accounts[publicKey]; ok {
+		return account.SatoshiBalance
+	}
+	return 0
+}
+
+// FailNext will make the next request to the path fail with the given status and message
+//
+// Calls are queued, so calling it twice will fail the next two requests (a client with
+// retries enabled will retry 429 and 5xx failures of GET requests)
+func (s *Server) FailNext(path string, statusCode int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], &failure{message: message, statusCode: statusCode})
+}
+
+// Payments will return all the payments made on the server
+func (s *Server) Payments() []*handcash.PaymentResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	payments := make([]*handcash.PaymentResponse, 0, len(s.payments))
+	for _, payment := range s.payments {
+		payments = append(payments, payment)
+	}
+	return payments
+}
+
+// handlerFunc is an endpoint handler for an authenticated account
+type handlerFunc func(account *Account, body []byte) (int, interface{})
+
+// handle will check the method, scripted failures and signature before calling the handler
+func (s *Server) handle(method string, handler handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+
+		// Check the method
+		if req.Method != method {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// Scripted failure?
+		if failures := s.failures[req.URL.Path]; len(failures) > 0 {
+			s.failures[req.URL.Path] = failures[1:]
+			writeError(w, failures[0].statusCode, failures[0].message)
+			return
+		}
+
+		// Check the signature
+		if err := handcash.VerifySignedRequestWithCache(req, s.MaxSkew, s.replays); err != nil {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		// Read the body
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid body")
+			return
+		}
+
+		// Find the account
+		account, ok := s.accounts[req.Header.Get("oauth-publickey")]
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "Invalid authentication")
+			return
+		}
+
+		statusCode, response := handler(account, body)
+		if message, isError := response.(string); isError {
+			writeError(w, statusCode, message)
+			return
+		}
+		writeJSON(w, statusCode, response)
+	}
+}
+
+// profile will return the account profile
+func (s *Server) profile(account *Account, _ []byte) (int, interface{}) {
+	return http.StatusOK, account.Profile
+}
+
+// spendableBalance will return the account balance
+func (s *Server) spendableBalance(account *Account, body []byte) (int, interface{}) {
+	params := new(handcash.BalanceRequest)
+	if err := json.Unmarshal(body, params); err != nil || len(params.CurrencyCode) == 0 {
+		return http.StatusBadRequest, "Invalid currency code"
+	}
+	return http.StatusOK, &handcash.SpendableBalanceResponse{
+		CurrencyCode:            params.CurrencyCode,
+		SpendableFiatBalance:    s.toFiat(account.SatoshiBalance, params.CurrencyCode),
+		SpendableSatoshiBalance: account.SatoshiBalance,
+	}
+}
+
+// pay will make a payment from the account
+func (s *Server) pay(account *Account, body []byte) (int, interface{}) {
+	params := new(handcash.PayParameters)
+	if err := json.Unmarshal(body, params); err != nil || len(params.Receivers) == 0 {
+		return http.StatusBadRequest, "Invalid payment parameters"
+	}
+
+	// Total the receivers
+	var total uint64
+	amounts := make([]uint64, len(params.Receivers))
+	for i, receiver := range params.Receivers {
+		if receiver == nil || receiver.Amount <= 0 || len(receiver.To) == 0 {
+			return http.StatusBadRequest, "Invalid receiver"
+		}
+		amounts[i] = s.toSatoshis(receiver.Amount, receiver.CurrencyCode)
+		total += amounts[i]
+	}
+	if account.SatoshiBalance < total+s.SatoshiFees {
+		return http.StatusBadRequest, "Insufficient balance"
+	}
+
+	// Move the funds
+	account.SatoshiBalance -= total + s.SatoshiFees
+	participants := make([]*handcash.Participant, len(params.Receivers))
+	for i, receiver := range params.Receivers {
+		participant := &handcash.Participant{Alias: receiver.To, Type: handcash.ParticipantUser}
+		if to := s.findAccount(receiver.To); to != nil {
+			to.SatoshiBalance += amounts[i]
+			participant.DisplayName = to.Profile.PublicProfile.DisplayName
+			participant.ProfilePictureURL = to.Profile.PublicProfile.AvatarURL
+		}
+		participants[i] = participant
+	}
+
+	// Store the payment
+	fiatCurrency := account.Profile.PublicProfile.LocalCurrencyCode
+	if len(fiatCurrency) == 0 {
+		fiatCurrency = handcash.CurrencyUSD
+	}
+	payment := &handcash.PaymentResponse{
+		AppAction:        params.AppAction,
+		Attachments:      []*handcash.Attachment{},
+		FiatCurrencyCode: fiatCurrency,
+		FiatExchangeRate: s.ExchangeRate,
+		Note:             params.Description,
+		Participants:     participants,
+		SatoshiAmount:    total,
+		SatoshiFees:      s.SatoshiFees,
+		Time:             uint64(time.Now().Unix()),
+		TransactionID:    s.newTransactionID(body),
+		Type:             handcash.PaymentSend,
+	}
+	if params.Attachment != nil {
+		payment.Attachments = append(payment.Attachments, params.Attachment)
+	}
+	s.payments[payment.TransactionID] = payment
+	return http.StatusOK, payment
+}
+
+// payment will return a payment by transaction id
+func (s *Server) payment(_ *Account, body []byte) (int, interface{}) {
+	params := new(handcash.PaymentRequest)
+	if err := json.Unmarshal(body, params); err != nil || len(params.TransactionID) == 0 {
+		return http.StatusBadRequest, "Invalid transaction id"
+	}
+	payment, ok := s.payments[params.TransactionID]
+	if !ok {
+		return http.StatusNotFound, "Payment not found"
+	}
+	return http.StatusOK, payment
+}
+
+// findAccount will return the account for the handle or paymail (if found)
+func (s *Server) findAccount(to string) *Account {
+	for _, account := range s.accounts {
+		if strings.EqualFold(account.Profile.PublicProfile.Handle, to) ||
+			strings.EqualFold(account.Profile.PublicProfile.Paymail, to) {
+			return account
+		}
+	}
+	return nil
+}
+
+// newTransactionID will return a unique fake transaction id
+func (s *Server) newTransactionID(body []byte) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%s", len(s.payments), time.Now().UnixNano(), body)))
+	return hex.EncodeToString(hash[:])
+}
+
+// toSatoshis will convert the amount in the currency to satoshis
+func (s *Server) toSatoshis(amount float64, currencyCode handcash.CurrencyCode) uint64 {
+	switch currencyCode {
+	case handcash.CurrencySAT:
+		return uint64(math.Round(amount))
+	case handcash.CurrencyBSV:
+		return uint64(math.Round(amount * satoshisPerBitcoin))
+	default:
+		return uint64(math.Round(amount / s.ExchangeRate * satoshisPerBitcoin))
+	}
+}
+
+// toFiat will convert the satoshis to the currency
+func (s *Server) toFiat(satoshis uint64, currencyCode handcash.CurrencyCode) float64 {
+	switch currencyCode {
+	case handcash.CurrencySAT:
+		return float64(satoshis)
+	case handcash.CurrencyBSV:
+		return float64(satoshis) / satoshisPerBitcoin
+	default:
+		return float64(satoshis) / satoshisPerBitcoin * s.ExchangeRate
+	}
+}
+
+// publicKeyFromToken will return the compressed public key (hex) for the auth token
+func publicKeyFromToken(authToken string) (string, error) {
+	tokenBytes, err := hex.DecodeString(authToken)
+	if err != nil {
+		return "", fmt.Errorf("invalid auth token: %w", err)
+	}
+	_, publicKey := bec.PrivKeyFromBytes(bec.S256(), tokenBytes)
+	return hex.EncodeToString(publicKey.SerialiseCompressed()), nil
+}
+
+// writeError will write an error response in the same format as HandCash
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]string{"message": message})
+}
+
+// writeJSON will write the response as JSON
+func writeJSON(w http.ResponseWriter, statusCode int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,194 @@
+package handcashtest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-handcash-connect"
+)
+
+const (
+	// These values are used (GOSEC complains about the token)
+	testTokenPayer    = "68d8fadc95324afa853f00923e0b" + "86f06a76ceb7a6afbb1784e0dde8f43989a0"
+	testTokenReceiver = "1ea4df4a1b5e4bc3b2a8c1b0a7d4c7f2" + "d3e5f60718293a4b5c6d7e8f90a1b2c3"
+)
+
+// newTestServer will return a fake server with two accounts and a client pointed at it
+func newTestServer(t *testing.T) (*Server, *handcash.Client) {
+	server := NewServer()
+	t.Cleanup(server.Close)
+
+	require.NoError(t, server.AddAccount(testTokenPayer, &handcash.Profile{
+		PublicProfile: handcash.PublicProfile{
+			Handle:            "payer",
+			ID:                "1",
+			LocalCurrencyCode: handcash.CurrencyUSD,
+			Paymail:           "payer@handcash.io",
+		},
+		PrivateProfile: handcash.PrivateProfile{Email: "payer@domain.com"},
+	}, 1000000))
+	require.NoError(t, server.AddAccount(testTokenReceiver, &handcash.Profile{
+		PublicProfile: handcash.PublicProfile{
+			DisplayName: "Receiver",
+			Handle:      "receiver",
+			ID:          "2",
+			Paymail:     "receiver@handcash.io",
+		},
+	}, 0))
+
+	// Retries are disabled so scripted failures are not retried
+	options := handcash.DefaultClientOptions()
+	options.RequestRetryCount = 0
+	client, err := handcash.NewClientWithEnvironment(options, nil, server.Environment())
+	require.NoError(t, err)
+	return server, client
+}
+
+func TestServer_Profile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid profile", func(t *testing.T) {
+		_, client := newTestServer(t)
+		profile, err := client.GetProfile(context.Background(), testTokenPayer)
+		require.NoError(t, err)
+		assert.Equal(t, "payer", profile.PublicProfile.Handle)
+		assert.Equal(t, "payer@domain.com", profile.PrivateProfile.Email)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		_, client := newTestServer(t)
+		profile, err := client.GetProfile(context.Background(), "0000000001")
+		assert.Error(t, err)
+		assert.Nil(t, profile)
+		assert.True(t, handcash.IsUnauthorized(err))
+	})
+
+	t.Run("scripted failure", func(t *testing.T) {
+		server, client := newTestServer(t)
+		server.FailNext(PathProfile, http.StatusInternalServerError, "Something went wrong")
+
+		profile, err := client.GetProfile(context.Background(), testTokenPayer)
+		assert.Error(t, err)
+		assert.Nil(t, profile)
+		assert.Equal(t, "Something went wrong", err.Error())
+
+		// Only the next request fails
+		profile, err = client.GetProfile(context.Background(), testTokenPayer)
+		assert.NoError(t, err)
+		assert.NotNil(t, profile)
+	})
+}
+
+func TestServer_SpendableBalance(t *testing.T) {
+	t.Parallel()
+
+	t.Run("balance in fiat", func(t *testing.T) {
+		_, client := newTestServer(t)
+		balance, err := client.GetSpendableBalance(context.Background(), testTokenPayer, handcash.CurrencyUSD)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1000000), balance.SpendableSatoshiBalance)
+		assert.Equal(t, 0.5, balance.SpendableFiatBalance)
+		assert.Equal(t, handcash.CurrencyUSD, balance.CurrencyCode)
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		server, client := newTestServer(t)
+		server.FailNext(PathSpendableBalance, http.StatusTooManyRequests, "Too many requests")
+		balance, err := client.GetSpendableBalance(context.Background(), testTokenPayer, handcash.CurrencyUSD)
+		assert.Nil(t, balance)
+		assert.True(t, handcash.IsRateLimited(err))
+	})
+}
+
+func TestServer_Pay(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid payment", func(t *testing.T) {
+		server, client := newTestServer(t)
+		server.SatoshiFees = 100
+
+		payment, err := client.Pay(context.Background(), testTokenPayer, &handcash.PayParameters{
+			AppAction:   handcash.AppActionTip,
+			Attachment:  &handcash.Attachment{Format: handcash.AttachmentFormatJSON, Value: map[string]string{"some": "data"}},
+			Description: "Thanks!",
+			Receivers: []*handcash.Payment{
+				{Amount: 0.01, CurrencyCode: handcash.CurrencyUSD, To: "receiver"},
+				{Amount: 500, CurrencyCode: handcash.CurrencySAT, To: "someone@moneybutton.com"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 64, len(payment.TransactionID))
+		assert.Equal(t, uint64(20500), payment.SatoshiAmount)
+		assert.Equal(t, uint64(100), payment.SatoshiFees)
+		assert.Equal(t, "Thanks!", payment.Note)
+		assert.Equal(t, 2, len(payment.Participants))
+		assert.Equal(t, "Receiver", payment.Participants[0].DisplayName)
+		assert.Equal(t, 1, len(payment.Attachments))
+
+		assert.Equal(t, uint64(1000000-20500-100), server.Balance(testTokenPayer))
+		assert.Equal(t, uint64(20000), server.Balance(testTokenReceiver))
+		assert.Equal(t, 1, len(server.Payments()))
+
+		// Fetch the payment
+		var found *handcash.PaymentResponse
+		found, err = client.GetPayment(context.Background(), testTokenPayer, payment.TransactionID)
+		require.NoError(t, err)
+		assert.Equal(t, payment.TransactionID, found.TransactionID)
+		assert.Equal(t, payment.SatoshiAmount, found.SatoshiAmount)
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		server, client := newTestServer(t)
+		payment, err := client.Pay(context.Background(), testTokenReceiver, &handcash.PayParameters{
+			Receivers: []*handcash.Payment{{Amount: 1, CurrencyCode: handcash.CurrencySAT, To: "payer"}},
+		})
+		assert.Nil(t, payment)
+		assert.True(t, handcash.IsInsufficientBalance(err))
+		assert.Equal(t, uint64(1000000), server.Balance(testTokenPayer))
+	})
+
+	t.Run("payment not found", func(t *testing.T) {
+		_, client := newTestServer(t)
+		payment, err := client.GetPayment(context.Background(), testTokenPayer, "unknown")
+		assert.Nil(t, payment)
+		assert.Error(t, err)
+	})
+}
+
+func TestServer_Signature(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing headers", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		resp, err := http.Get(server.URL + PathProfile)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		resp, err := http.Post(server.URL+PathProfile, "application/json", nil)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		req, err := http.NewRequest(http.MethodGet, server.URL+PathProfile, nil)
+		require.NoError(t, err)
+		req.Header.Set("oauth-publickey", "0275e7081e5b6e73c94998098e075c0ed888d1eb33c721ee38ee741648b108c90d")
+		req.Header.Set("oauth-signature", "30450221009b613aa82657e28471406d3a390688bc2dceece75cf73d89088d447cc9d1f5c502200a1ff4f02f5dfdb7f48b51b6dd2a0f586f591b07a89d5ad846392fca8ae0c856")
+		req.Header.Set("oauth-timestamp", "2020-12-10T16:31:24.304Z")
+
+		var resp *http.Response
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,166 @@
+package handcash
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long the in-memory store keeps a payment for a key
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+var (
+
+	// ErrIdempotencyKeyReused is returned when an idempotency key is used again with
+	// different payment parameters
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used with different payment parameters")
+
+	// ErrPaymentOutcomeUnknown is returned when the payment for the idempotency key may have
+	// been made (IE: the request timed out). It is never sent again with the same key: check
+	// the payment history and use a new key if it was not made.
+	ErrPaymentOutcomeUnknown = errors.New("payment outcome is unknown")
+)
+
+// IdempotencyRecord is the payment made (or being made) with an idempotency key
+type IdempotencyRecord struct {
+	ParamsHash string           `json:"paramsHash"`        // Hash of the PayParameters used with the key
+	Payment    *PaymentResponse `json:"payment,omitempty"` // Payment made (nil if in flight or the outcome is unknown)
+}
+
+// IdempotencyStore stores the payment made for each idempotency key
+//
+// The key is recorded before the payment is sent, so a payment with an unknown outcome
+// is never sent twice. Set ClientOptions.IdempotencyStore to share the store between
+// clients or to persist it (the in-memory store is used by default)
+type IdempotencyStore interface {
+
+	// Delete removes the record for the key (the payment was not made)
+	Delete(ctx context.Context, key string) error
+
+	// Get returns the record for the key (nil if not found)
+	Get(ctx context.Context, key string) (*IdempotencyRecord, error)
+
+	// Set stores the record for the key
+	Set(ctx context.Context, key string, record *IdempotencyRecord) error
+}
+
+// NewIdempotencyKey will return a new random idempotency key for PayParameters
+func NewIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashPayParameters will return the hash of the payment parameters (to detect a reused key)
+func hashPayParameters(payParams *PayParameters) (string, error) {
+	data, err := json.Marshal(payParams)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore (records expire after the TTL)
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*storedRecord
+	ttl     time.Duration
+}
+
+// storedRecord is a record with its expiration
+type storedRecord struct {
+	expires time.Time
+	record  *IdempotencyRecord
+}
+
+// NewMemoryIdempotencyStore will return a new in-memory store
+// (DefaultIdempotencyTTL is used if the TTL is not set)
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &MemoryIdempotencyStore{
+		records: make(map[string]*storedRecord),
+		ttl:     ttl,
+	}
+}
+
+// Delete will remove the record for the key
+func (m *MemoryIdempotencyStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	return nil
+}
+
+// Get will return the record for the key (nil if not found or expired)
+func (m *MemoryIdempotencyStore) Get(_ context.Context, key string) (*IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.records[key]
+	if !ok || time.Now().After(stored.expires) {
+		return nil, nil
+	}
+	return stored.record, nil
+}
+
+// Set will store the record for the key (expired records are removed)
+func (m *MemoryIdempotencyStore) Set(_ context.Context, key string, record *IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for k, stored := range m.records {
+		if now.After(stored.expires) {
+			delete(m.records, k)
+		}
+	}
+	m.records[key] = &storedRecord{expires: now.Add(m.ttl), record: record}
+	return nil
+}
+
+// keyLocker serializes the payments made with the same idempotency key
+type keyLocker struct {
+	locks map[string]*keyLock
+	mu    sync.Mutex
+}
+
+// keyLock is the lock for a single key
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock will lock the key and return the function to unlock it
+func (k *keyLocker) lock(key string) (unlock func()) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyLock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = new(keyLock)
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		k.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
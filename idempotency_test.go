@@ -0,0 +1,236 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHTTPCountingPay counts the payment requests
+type mockHTTPCountingPay struct {
+	mockHTTPPay
+	requests int32
+}
+
+// Do is a mock http request
+func (m *mockHTTPCountingPay) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&m.requests, 1)
+	return m.mockHTTPPay.Do(req)
+}
+
+// mockHTTPFlakyPay fails the first payment request (with the status, or a timeout if not set)
+type mockHTTPFlakyPay struct {
+	mockHTTPCountingPay
+	status int
+}
+
+// Do is a mock http request
+func (m *mockHTTPFlakyPay) Do(req *http.Request) (*http.Response, error) {
+	if atomic.LoadInt32(&m.requests) > 0 {
+		return m.mockHTTPCountingPay.Do(req)
+	}
+	atomic.AddInt32(&m.requests, 1)
+	if m.status == 0 {
+		return nil, errors.New("request timed out")
+	}
+	return &http.Response{
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"message": "rejected"}`)),
+		StatusCode: m.status,
+	}, nil
+}
+
+// newTestPayParameters returns valid payment parameters
+func newTestPayParameters(idempotencyKey string) *PayParameters {
+	return &PayParameters{
+		IdempotencyKey: idempotencyKey,
+		Receivers:      []*Payment{{Amount: 0.01, CurrencyCode: CurrencyUSD, To: "mrz@moneybutton.com"}},
+	}
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get, set and delete", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore(0)
+		assert.Equal(t, DefaultIdempotencyTTL, store.ttl)
+
+		record, err := store.Get(context.Background(), "key")
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		require.NoError(t, store.Set(context.Background(), "key", &IdempotencyRecord{
+			ParamsHash: "hash",
+			Payment:    &PaymentResponse{TransactionID: "txid"},
+		}))
+		record, err = store.Get(context.Background(), "key")
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, "hash", record.ParamsHash)
+		assert.Equal(t, "txid", record.Payment.TransactionID)
+
+		require.NoError(t, store.Delete(context.Background(), "key"))
+		record, err = store.Get(context.Background(), "key")
+		require.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("expired record", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore(time.Millisecond)
+		require.NoError(t, store.Set(context.Background(), "key", &IdempotencyRecord{ParamsHash: "hash"}))
+		time.Sleep(5 * time.Millisecond)
+
+		record, err := store.Get(context.Background(), "key")
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		require.NoError(t, store.Set(context.Background(), "other", &IdempotencyRecord{ParamsHash: "hash"}))
+		assert.Equal(t, 1, len(store.records))
+	})
+}
+
+func TestNewIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	key, err := NewIdempotencyKey()
+	require.NoError(t, err)
+	assert.Equal(t, 32, len(key))
+
+	var other string
+	other, err = NewIdempotencyKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestClient_PayIdempotency(t *testing.T) {
+	t.Parallel()
+
+	t.Run("repeated key returns the original payment", func(t *testing.T) {
+		mock := &mockHTTPCountingPay{}
+		client := newTestClient(mock, EnvironmentBeta)
+
+		payment, err := client.Pay(context.Background(), "000000", newTestPayParameters("key-1"))
+		require.NoError(t, err)
+
+		var again *PaymentResponse
+		again, err = client.Pay(context.Background(), "000000", newTestPayParameters("key-1"))
+		require.NoError(t, err)
+		assert.Equal(t, payment, again)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&mock.requests))
+
+		_, err = client.Pay(context.Background(), "000000", newTestPayParameters("key-2"))
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&mock.requests))
+	})
+
+	t.Run("concurrent payments with the same key", func(t *testing.T) {
+		mock := &mockHTTPCountingPay{}
+		client := newTestClient(mock, EnvironmentBeta)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.Pay(context.Background(), "000000", newTestPayParameters("key"))
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), atomic.LoadInt32(&mock.requests))
+	})
+
+	t.Run("no key is never deduplicated", func(t *testing.T) {
+		mock := &mockHTTPCountingPay{}
+		client := newTestClient(mock, EnvironmentBeta)
+
+		for i := 0; i < 2; i++ {
+			_, err := client.Pay(context.Background(), "000000", newTestPayParameters(""))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&mock.requests))
+	})
+
+	t.Run("payments are never retried", func(t *testing.T) {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// The payment is made, but the response is lost
+			atomic.AddInt32(&requests, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		client := NewClient(nil, nil, EnvironmentBeta)
+		client.Environment = &Environment{APIURL: server.URL, Environment: "test"}
+
+		_, err := client.Pay(context.Background(), "000000", newTestPayParameters(""))
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+		// Not retried with a key (it is not sent to HandCash)
+		atomic.StoreInt32(&requests, 0)
+		_, err = client.Pay(context.Background(), "000000", newTestPayParameters("key"))
+		require.ErrorIs(t, err, ErrPaymentOutcomeUnknown)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+		// Not sent again with the same key
+		_, err = client.Pay(context.Background(), "000000", newTestPayParameters("key"))
+		require.ErrorIs(t, err, ErrPaymentOutcomeUnknown)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("timed out payment is not sent again", func(t *testing.T) {
+		mock := &mockHTTPFlakyPay{}
+		client := newTestClient(mock, EnvironmentBeta)
+
+		_, err := client.Pay(context.Background(), "000000", newTestPayParameters("key"))
+		require.ErrorIs(t, err, ErrPaymentOutcomeUnknown)
+
+		_, err = client.Pay(context.Background(), "000000", newTestPayParameters("key"))
+		require.ErrorIs(t, err, ErrPaymentOutcomeUnknown)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&mock.requests))
+
+		// A new key pays again (once the payment is known to have failed)
+		_, err = client.Pay(context.Background(), "000000", newTestPayParameters("new-key"))
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&mock.requests))
+	})
+
+	t.Run("rejected payment releases the key", func(t *testing.T) {
+		mock := &mockHTTPFlakyPay{status: http.StatusBadRequest}
+		client := newTestClient(mock, EnvironmentBeta)
+
+		_, err := client.Pay(context.Background(), "000000", newTestPayParameters("key"))
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrPaymentOutcomeUnknown))
+
+		var payment *PaymentResponse
+		payment, err = client.Pay(context.Background(), "000000", newTestPayParameters("key"))
+		require.NoError(t, err)
+		require.NotNil(t, payment)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&mock.requests))
+	})
+
+	t.Run("repeated key with different parameters", func(t *testing.T) {
+		mock := &mockHTTPCountingPay{}
+		client := newTestClient(mock, EnvironmentBeta)
+
+		_, err := client.Pay(context.Background(), "000000", newTestPayParameters("key"))
+		require.NoError(t, err)
+
+		payParams := newTestPayParameters("key")
+		payParams.Receivers[0].Amount = 0.02
+		_, err = client.Pay(context.Background(), "000000", payParams)
+		require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&mock.requests))
+	})
+}
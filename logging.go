@@ -0,0 +1,139 @@
+package handcash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces any secret in the logs
+const redactedValue = "[REDACTED]"
+
+// redactedHeaders are the request headers that are never logged
+var redactedHeaders = map[string]bool{
+	headerAppSecret:   true,
+	"authorization":   true,
+	"oauth-publickey": true,
+	"oauth-signature": true,
+}
+
+// redactedFields are the JSON fields (and query params) that are never logged (lowercase)
+var redactedFields = map[string]bool{
+	"appsecret":       true,
+	"authtoken":       true,
+	"email":           true,
+	"oauth-publickey": true,
+	"oauth-signature": true,
+	"phonenumber":     true,
+}
+
+// logRequest will log a summary of the request using the client logger
+//
+// Successful requests are logged at the info level and failed requests at the
+// error level. The (redacted) headers and bodies are only added at the debug level.
+func (c *Client) logRequest(ctx context.Context, request *http.Request, response *RequestResponse,
+	endpoint string, duration time.Duration, retries int) {
+
+	logger := c.Options.Logger
+
+	level := slog.LevelInfo
+	if response.Error != nil {
+		level = slog.LevelError
+	}
+
+	attributes := []slog.Attr{
+		slog.String("method", response.Method),
+		slog.String("endpoint", endpoint),
+		slog.String("url", redactURL(response.URL)),
+		slog.Int("status_code", response.StatusCode),
+		slog.Duration("duration", duration),
+		slog.Int("retries", retries),
+	}
+	if response.Error != nil {
+		attributes = append(attributes, slog.String("error", response.Error.Error()))
+	}
+
+	// Add the details (debugging only)
+	if logger.Enabled(ctx, slog.LevelDebug) {
+		if request != nil {
+			attributes = append(attributes, slog.Any("headers", redactHeaders(request.Header)))
+		}
+		if len(response.PostData) > 0 {
+			attributes = append(attributes, slog.String("request_body", redactBody([]byte(response.PostData))))
+		}
+		attributes = append(attributes, slog.String("response_body", redactBody(response.BodyContents)))
+	}
+
+	logger.LogAttrs(ctx, level, "handcash request", attributes...)
+}
+
+// redactHeaders will return a copy of the headers with the secrets masked
+func redactHeaders(headers http.Header) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for name, values := range headers {
+		if redactedHeaders[strings.ToLower(name)] {
+			redacted[name] = redactedValue
+			continue
+		}
+		redacted[name] = strings.Join(values, ", ")
+	}
+	return redacted
+}
+
+// redactURL will mask any secrets in the URL query
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || len(u.RawQuery) == 0 {
+		return rawURL
+	}
+	query := u.Query()
+	for name := range query {
+		if redactedFields[strings.ToLower(name)] {
+			query.Set(name, redactedValue)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// redactBody will mask any secrets in the JSON body
+//
+// A body that is not JSON is replaced by its size, as it cannot be safely redacted
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Sprintf("[%d bytes]", len(body))
+	}
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return fmt.Sprintf("[%d bytes]", len(body))
+	}
+	return string(redacted)
+}
+
+// redactValue will mask the secret fields in the decoded JSON value (recursively)
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if redactedFields[strings.ToLower(key)] {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
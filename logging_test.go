@@ -0,0 +1,113 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLoggingClient returns a client with a JSON logger (using a custom HTTP interface)
+func newTestLoggingClient(httpClient httpInterface, level slog.Level) (*Client, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	options := DefaultClientOptions()
+	options.Logger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: level}))
+	client := NewClient(options, nil, EnvironmentBeta)
+	client.httpClient = httpClient
+	return client, buf
+}
+
+func TestClient_Logging(t *testing.T) {
+	t.Parallel()
+
+	t.Run("info summary", func(t *testing.T) {
+		client, buf := newTestLoggingClient(&mockHTTPGetProfile{}, slog.LevelInfo)
+		_, err := client.GetProfile(context.Background(), "000000")
+		require.NoError(t, err)
+
+		record := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, "INFO", record["level"])
+		assert.Equal(t, "handcash request", record["msg"])
+		assert.Equal(t, http.MethodGet, record["method"])
+		assert.Equal(t, endpointProfileCurrent, record["endpoint"])
+		assert.Equal(t, float64(http.StatusOK), record["status_code"])
+		assert.NotContains(t, record, "headers")
+		assert.NotContains(t, record, "response_body")
+	})
+
+	t.Run("debug details are redacted", func(t *testing.T) {
+		client, buf := newTestLoggingClient(&mockHTTPGetProfile{}, slog.LevelDebug)
+		_, err := client.GetProfile(context.Background(), "000000")
+		require.NoError(t, err)
+
+		signed, err := client.getSignedRequest(
+			http.MethodGet, endpointProfileCurrent, "000000", &requestBody{authToken: "000000"}, currentISOTimestamp(),
+		)
+		require.NoError(t, err)
+
+		output := buf.String()
+		assert.Contains(t, output, `"headers"`)
+		assert.Contains(t, output, "MisterZ")
+		assert.Contains(t, output, redactedValue)
+		assert.NotContains(t, output, signed.Headers.OauthPublicKey)
+		assert.NotContains(t, output, "email@domain.com")
+		assert.NotContains(t, output, "+15554443333")
+	})
+
+	t.Run("errors are logged", func(t *testing.T) {
+		client, buf := newTestLoggingClient(&mockHTTPBadRequest{}, slog.LevelInfo)
+		_, err := client.GetSpendableBalance(context.Background(), "000000", CurrencyUSD)
+		require.Error(t, err)
+
+		record := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, "ERROR", record["level"])
+		assert.Equal(t, "bad request", record["error"])
+	})
+
+	t.Run("logging disabled", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetProfile{}, EnvironmentBeta)
+		profile, err := client.GetProfile(context.Background(), "000000")
+		assert.NoError(t, err)
+		assert.NotNil(t, profile)
+	})
+}
+
+func TestRedaction(t *testing.T) {
+	t.Parallel()
+
+	t.Run("headers", func(t *testing.T) {
+		headers := http.Header{}
+		headers.Set("oauth-publickey", "02abc")
+		headers.Set("oauth-signature", "3045")
+		headers.Set("oauth-timestamp", "2021-01-01T00:00:00.000Z")
+		redacted := redactHeaders(headers)
+		assert.Equal(t, redactedValue, redacted["Oauth-Publickey"])
+		assert.Equal(t, redactedValue, redacted["Oauth-Signature"])
+		assert.Equal(t, "2021-01-01T00:00:00.000Z", redacted["Oauth-Timestamp"])
+	})
+
+	t.Run("nested body", func(t *testing.T) {
+		body := redactBody([]byte(`{"authToken":"secret","items":[{"Email":"a@b.com","handle":"MisterZ"}]}`))
+		assert.Equal(t, `{"authToken":"[REDACTED]","items":[{"Email":"[REDACTED]","handle":"MisterZ"}]}`, body)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		assert.Equal(t, "[6 bytes]", redactBody([]byte("secret")))
+		assert.Equal(t, "", redactBody(nil))
+	})
+
+	t.Run("url query", func(t *testing.T) {
+		assert.Equal(t,
+			"https://example.com/callback?authToken=%5BREDACTED%5D&state=xyz",
+			redactURL("https://example.com/callback?authToken=secret&state=xyz"),
+		)
+		assert.Equal(t, "https://example.com/path", redactURL("https://example.com/path"))
+	})
+}
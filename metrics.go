@@ -0,0 +1,193 @@
+package handcash
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsCollector is invoked by the client around every HandCash Connect request
+//
+// Set ClientOptions.MetricsCollector to enable (IE: NewPrometheusCollector())
+type MetricsCollector interface {
+
+	// ObserveRequest is called once per request (after any retries)
+	// The status code is zero if no response was received
+	ObserveRequest(endpoint, method string, statusCode int, duration time.Duration, retries int, err error)
+
+	// AddSatoshisPaid is called after every successful Pay()
+	AddSatoshisPaid(satoshis uint64)
+}
+
+// DefaultLatencyBuckets are the default histogram buckets (in seconds) for request latency
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metric names used by the PrometheusCollector
+const (
+	metricRequestDuration = "handcash_request_duration_seconds"
+	metricRequestErrors   = "handcash_request_errors_total"
+	metricRequestRetries  = "handcash_request_retries_total"
+	metricRequests        = "handcash_requests_total"
+	metricSatoshisPaid    = "handcash_satoshis_paid_total"
+)
+
+// PrometheusCollector is a MetricsCollector that serves the metrics in the
+// Prometheus text format (it can be used directly as an http.Handler)
+type PrometheusCollector struct {
+	buckets      []float64
+	durations    map[string]*histogram // Keyed by endpoint and method labels
+	errors       map[string]uint64     // Keyed by endpoint and status code labels
+	mu           sync.Mutex
+	requests     map[string]uint64 // Keyed by endpoint, method and status code labels
+	retries      map[string]uint64 // Keyed by endpoint label
+	satoshisPaid uint64
+}
+
+// histogram is a single latency histogram
+type histogram struct {
+	counts []uint64 // Count per bucket (not cumulative)
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheusCollector will return a new collector using the given latency
+// buckets (in seconds), or DefaultLatencyBuckets if none are given
+func NewPrometheusCollector(buckets ...float64) *PrometheusCollector {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+
+	return &PrometheusCollector{
+		buckets:   sorted,
+		durations: make(map[string]*histogram),
+		errors:    make(map[string]uint64),
+		requests:  make(map[string]uint64),
+		retries:   make(map[string]uint64),
+	}
+}
+
+// ObserveRequest will record the request
+func (p *PrometheusCollector) ObserveRequest(endpoint, method string, statusCode int,
+	duration time.Duration, retries int, err error) {
+
+	status := strconv.Itoa(statusCode)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Latency
+	key := formatLabels("endpoint", endpoint, "method", method)
+	h, ok := p.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(p.buckets))}
+		p.durations[key] = h
+	}
+	seconds := duration.Seconds()
+	for i, bucket := range p.buckets {
+		if seconds <= bucket {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+
+	// Requests, errors and retries
+	p.requests[formatLabels("endpoint", endpoint, "method", method, "status_code", status)]++
+	if err != nil {
+		p.errors[formatLabels("endpoint", endpoint, "status_code", status)]++
+	}
+	if retries > 0 {
+		p.retries[formatLabels("endpoint", endpoint)] += uint64(retries)
+	}
+}
+
+// AddSatoshisPaid will add to the total satoshis paid
+func (p *PrometheusCollector) AddSatoshisPaid(satoshis uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.satoshisPaid += satoshis
+}
+
+// ServeHTTP will write the metrics in the Prometheus text format
+func (p *PrometheusCollector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = p.WriteMetrics(w)
+}
+
+// WriteMetrics will write the metrics in the Prometheus text format
+func (p *PrometheusCollector) WriteMetrics(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+
+	// Latency histograms
+	writeHeader(&b, metricRequestDuration, "histogram", "Latency of HandCash Connect requests in seconds.")
+	for _, key := range sortedKeys(p.durations) {
+		h := p.durations[key]
+		var cumulative uint64
+		for i, bucket := range p.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "%s_bucket{%s,le=\"%s\"} %d\n", metricRequestDuration, key, formatFloat(bucket), cumulative)
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d\n", metricRequestDuration, key, h.count)
+		fmt.Fprintf(&b, "%s_sum{%s} %s\n", metricRequestDuration, key, formatFloat(h.sum))
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", metricRequestDuration, key, h.count)
+	}
+
+	// Counters
+	writeCounter(&b, metricRequests, "Total HandCash Connect requests.", p.requests)
+	writeCounter(&b, metricRequestErrors, "Total failed HandCash Connect requests.", p.errors)
+	writeCounter(&b, metricRequestRetries, "Total HandCash Connect request retries.", p.retries)
+	writeHeader(&b, metricSatoshisPaid, "counter", "Total satoshis paid using Pay.")
+	fmt.Fprintf(&b, "%s %d\n", metricSatoshisPaid, p.satoshisPaid)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeHeader will write the HELP and TYPE lines for the metric
+func writeHeader(b *strings.Builder, name, metricType, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// writeCounter will write a counter with labels
+func writeCounter(b *strings.Builder, name, help string, values map[string]uint64) {
+	writeHeader(b, name, "counter", help)
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(b, "%s{%s} %d\n", name, key, values[key])
+	}
+}
+
+// formatLabels will format the label pairs (name, value, ...) for the text format
+func formatLabels(pairs ...string) string {
+	labels := make([]string, 0, len(pairs)/2)
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels = append(labels, pairs[i]+`="`+replacer.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(labels, ",")
+}
+
+// formatFloat will format the float for the text format
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// sortedKeys will return the map keys in order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
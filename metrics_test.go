@@ -0,0 +1,130 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMetricsClient returns a client with a Prometheus collector (using a custom HTTP interface)
+func newTestMetricsClient(httpClient httpInterface) (*Client, *PrometheusCollector) {
+	collector := NewPrometheusCollector()
+	options := DefaultClientOptions()
+	options.MetricsCollector = collector
+	client := NewClient(options, nil, EnvironmentBeta)
+	client.httpClient = httpClient
+	return client, collector
+}
+
+// writeTestMetrics will return the collector output as a string
+func writeTestMetrics(t *testing.T, collector *PrometheusCollector) string {
+	var buf bytes.Buffer
+	require.NoError(t, collector.WriteMetrics(&buf))
+	return buf.String()
+}
+
+func TestPrometheusCollector(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty collector", func(t *testing.T) {
+		output := writeTestMetrics(t, NewPrometheusCollector())
+		assert.Contains(t, output, "# TYPE "+metricRequestDuration+" histogram\n")
+		assert.Contains(t, output, "# TYPE "+metricRequests+" counter\n")
+		assert.Contains(t, output, metricSatoshisPaid+" 0\n")
+	})
+
+	t.Run("histogram buckets", func(t *testing.T) {
+		collector := NewPrometheusCollector(1, 0.1)
+		collector.ObserveRequest("/v1/test", http.MethodGet, http.StatusOK, 50*time.Millisecond, 0, nil)
+		collector.ObserveRequest("/v1/test", http.MethodGet, http.StatusOK, 500*time.Millisecond, 0, nil)
+		collector.ObserveRequest("/v1/test", http.MethodGet, http.StatusOK, 5*time.Second, 0, nil)
+
+		output := writeTestMetrics(t, collector)
+		labels := `endpoint="/v1/test",method="GET"`
+		assert.Contains(t, output, metricRequestDuration+`_bucket{`+labels+`,le="0.1"} 1`+"\n")
+		assert.Contains(t, output, metricRequestDuration+`_bucket{`+labels+`,le="1"} 2`+"\n")
+		assert.Contains(t, output, metricRequestDuration+`_bucket{`+labels+`,le="+Inf"} 3`+"\n")
+		assert.Contains(t, output, metricRequestDuration+`_sum{`+labels+`} 5.55`+"\n")
+		assert.Contains(t, output, metricRequestDuration+`_count{`+labels+`} 3`+"\n")
+	})
+
+	t.Run("errors and retries", func(t *testing.T) {
+		collector := NewPrometheusCollector()
+		collector.ObserveRequest("/v1/test", http.MethodPost, http.StatusBadRequest, time.Millisecond, 2, fmt.Errorf("bad request"))
+		collector.ObserveRequest("/v1/test", http.MethodPost, http.StatusOK, time.Millisecond, 1, nil)
+
+		output := writeTestMetrics(t, collector)
+		assert.Contains(t, output, metricRequests+`{endpoint="/v1/test",method="POST",status_code="400"} 1`+"\n")
+		assert.Contains(t, output, metricRequests+`{endpoint="/v1/test",method="POST",status_code="200"} 1`+"\n")
+		assert.Contains(t, output, metricRequestErrors+`{endpoint="/v1/test",status_code="400"} 1`+"\n")
+		assert.NotContains(t, output, metricRequestErrors+`{endpoint="/v1/test",status_code="200"}`)
+		assert.Contains(t, output, metricRequestRetries+`{endpoint="/v1/test"} 3`+"\n")
+	})
+
+	t.Run("label escaping", func(t *testing.T) {
+		assert.Equal(t, `endpoint="a\"b\\c"`, formatLabels("endpoint", `a"b\c`))
+	})
+
+	t.Run("serve http", func(t *testing.T) {
+		collector := NewPrometheusCollector()
+		collector.AddSatoshisPaid(1000)
+		collector.AddSatoshisPaid(234)
+
+		recorder := httptest.NewRecorder()
+		collector.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain")
+		assert.Contains(t, recorder.Body.String(), metricSatoshisPaid+" 1234\n")
+	})
+}
+
+func TestClient_Metrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get profile", func(t *testing.T) {
+		client, collector := newTestMetricsClient(&mockHTTPGetProfile{})
+		_, err := client.GetProfile(context.Background(), "000000")
+		require.NoError(t, err)
+
+		output := writeTestMetrics(t, collector)
+		assert.Contains(t, output, metricRequests+`{endpoint="`+endpointProfileCurrent+`",method="GET",status_code="200"} 1`)
+		assert.Contains(t, output, metricRequestDuration+`_count{endpoint="`+endpointProfileCurrent+`",method="GET"} 1`)
+	})
+
+	t.Run("failed request", func(t *testing.T) {
+		client, collector := newTestMetricsClient(&mockHTTPBadRequest{})
+		_, err := client.GetSpendableBalance(context.Background(), "000000", CurrencyUSD)
+		require.Error(t, err)
+
+		output := writeTestMetrics(t, collector)
+		assert.Contains(t, output, metricRequestErrors+`{endpoint="`+endpointGetSpendableBalanceRequest+`",status_code="400"} 1`)
+	})
+
+	t.Run("satoshis paid", func(t *testing.T) {
+		client, collector := newTestMetricsClient(&mockHTTPPay{})
+		_, err := client.Pay(context.Background(), "000000", &PayParameters{
+			Receivers: []*Payment{{Amount: 0.01, CurrencyCode: CurrencyUSD, To: "mrz@moneybutton.com"}},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, writeTestMetrics(t, collector), metricSatoshisPaid+" 5372\n")
+	})
+}
+
+// ExamplePrometheusCollector example using the Prometheus collector
+func ExamplePrometheusCollector() {
+	collector := NewPrometheusCollector()
+	options := DefaultClientOptions()
+	options.MetricsCollector = collector
+	client := NewClient(options, nil, EnvironmentBeta)
+
+	// Serve the metrics (IE: http.Handle("/metrics", collector))
+	fmt.Printf("metrics enabled: %t", client.Options.MetricsCollector == collector)
+	// Output:metrics enabled: true
+}
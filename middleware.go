@@ -0,0 +1,66 @@
+package handcash
+
+import (
+	"context"
+	"net/http"
+)
+
+// DoFunc performs a single HTTP request (the same signature as http.RoundTripper)
+type DoFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps every request made to the HandCash Connect API
+//
+// Use RequestInfoFromContext(req.Context()) to access the request metadata
+type Middleware func(next DoFunc) DoFunc
+
+// RequestInfo is the metadata of a signed HandCash Connect request
+type RequestInfo struct {
+	Endpoint  string `json:"endpoint"`   // Endpoint is the API endpoint (IE: /v1/connect/wallet/pay)
+	Method    string `json:"method"`     // Method is the HTTP method used
+	PublicKey string `json:"public_key"` // PublicKey is the oauth-publickey header
+	Signature string `json:"signature"`  // Signature is the oauth-signature header
+	Timestamp string `json:"timestamp"`  // Timestamp is the oauth-timestamp header
+	URL       string `json:"url"`        // URL is used for the request
+}
+
+// requestInfoKey is the context key for the request info
+type requestInfoKey struct{}
+
+// RequestInfoFromContext will return the request info (nil if not found)
+func RequestInfoFromContext(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
+
+// Use will add middleware to the client
+//
+// Middleware runs in the order it was added (the first is the outermost) and
+// should be added before the client is used
+func (c *Client) Use(middleware ...Middleware) {
+	for _, mw := range middleware {
+		if mw != nil {
+			c.middleware = append(c.middleware, mw)
+		}
+	}
+}
+
+// newRequestInfo will return the request info for the signed request
+func newRequestInfo(signed *signedRequest) *RequestInfo {
+	return &RequestInfo{
+		Endpoint:  signed.Endpoint,
+		Method:    signed.Method,
+		PublicKey: signed.Headers.OauthPublicKey,
+		Signature: signed.Headers.OauthSignature,
+		Timestamp: signed.Headers.OauthTimestamp,
+		URL:       signed.URI,
+	}
+}
+
+// do will fire the request through the middleware chain
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	next := c.httpClient.Do
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		next = c.middleware[i](next)
+	}
+	return next(req)
+}
@@ -0,0 +1,121 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Use(t *testing.T) {
+	t.Parallel()
+
+	t.Run("middleware order and request info", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetProfile{}, EnvironmentBeta)
+
+		var calls []string
+		var info *RequestInfo
+		client.Use(
+			func(next DoFunc) DoFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					calls = append(calls, "first")
+					info = RequestInfoFromContext(req.Context())
+					return next(req)
+				}
+			},
+			nil,
+			func(next DoFunc) DoFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					calls = append(calls, "second")
+					req.Header.Set("X-Custom", "value")
+					return next(req)
+				}
+			},
+		)
+
+		profile, err := client.GetProfile(context.Background(), "000000")
+		assert.NoError(t, err)
+		assert.NotNil(t, profile)
+		assert.Equal(t, []string{"first", "second"}, calls)
+
+		assert.NotNil(t, info)
+		assert.Equal(t, endpointProfileCurrent, info.Endpoint)
+		assert.Equal(t, http.MethodGet, info.Method)
+		assert.Equal(t, client.Environment.APIURL+endpointProfileCurrent, info.URL)
+		assert.NotEmpty(t, info.PublicKey)
+		assert.NotEmpty(t, info.Signature)
+		assert.NotEmpty(t, info.Timestamp)
+	})
+
+	t.Run("request info matches every signed attempt", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetProfile{}, EnvironmentBeta)
+		client.retryPolicy = DefaultRetryPolicy()
+		client.retryPolicy.InitialBackoff = 5 * time.Millisecond
+
+		var signatures []string
+		client.Use(func(next DoFunc) DoFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				info := RequestInfoFromContext(req.Context())
+				assert.Equal(t, req.Header.Get("oauth-signature"), info.Signature)
+				assert.Equal(t, req.Header.Get("oauth-timestamp"), info.Timestamp)
+				if signatures = append(signatures, info.Signature); len(signatures) == 1 {
+					return &http.Response{
+						StatusCode: http.StatusBadGateway,
+						Body:       ioutil.NopCloser(bytes.NewBufferString(`{}`)),
+					}, nil
+				}
+				return next(req)
+			}
+		})
+
+		_, err := client.GetProfile(context.Background(), testUserAuthToken)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(signatures))
+		assert.NotEqual(t, signatures[0], signatures[1])
+	})
+
+	t.Run("middleware can short circuit", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetProfile{}, EnvironmentBeta)
+		client.Use(func(next DoFunc) DoFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				return nil, fmt.Errorf("blocked")
+			}
+		})
+
+		profile, err := client.GetProfile(context.Background(), "000000")
+		assert.Error(t, err)
+		assert.Equal(t, "blocked", err.Error())
+		assert.Nil(t, profile)
+	})
+
+	t.Run("middleware can replace the response", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetProfile{}, EnvironmentBeta)
+		client.Use(func(next DoFunc) DoFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusUnauthorized,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(`{"message":"denied"}`)),
+				}, nil
+			}
+		})
+
+		profile, err := client.GetProfile(context.Background(), "000000")
+		assert.True(t, IsUnauthorized(err))
+		assert.Nil(t, profile)
+	})
+}
+
+func TestRequestInfoFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, RequestInfoFromContext(context.Background()))
+
+	info := &RequestInfo{Endpoint: endpointGetPayRequest}
+	ctx := context.WithValue(context.Background(), requestInfoKey{}, info)
+	assert.Equal(t, info, RequestInfoFromContext(ctx))
+}
@@ -3,8 +3,11 @@ package handcash
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 /*
@@ -41,36 +44,128 @@ import (
 
 // Pay makes a new payment request to the HandCash Connect API
 //
+// The request is never retried, as the PayParameters.IdempotencyKey is not sent to HandCash
+// (a payment that failed with an error may still have been made, check it before paying
+// again). The key is recorded before paying: a repeated key returns the original payment
+// (from the IdempotencyStore), ErrPaymentOutcomeUnknown if the payment may have been made,
+// or ErrIdempotencyKeyReused if the parameters changed. The key is only released when the
+// payment definitely failed (IE: a 4xx response).
+//
 // Specs: https://github.com/HandCash/handcash-connect-sdk-js/blob/master/src/api/http_request_factory.js
 func (c *Client) Pay(ctx context.Context, authToken string,
-	payParams *PayParameters) (*PaymentResponse, error) {
+	payParams *PayParameters) (_ *PaymentResponse, err error) {
+
+	// Start the span
+	ctx, span := c.startSpan(ctx, "Pay")
+	defer func() {
+		endSpan(span, err)
+	}()
 
 	// Make sure we have an auth token
 	if len(authToken) == 0 {
 		return nil, fmt.Errorf("missing auth token")
 	}
 
-	// Make sure we have payment params
-	if payParams == nil || len(payParams.Receivers) == 0 {
+	// Make sure we have valid payment params (before signing)
+	if payParams == nil {
 		return nil, fmt.Errorf("invalid payment parameters")
+	} else if err = payParams.Validate(); err != nil {
+		return nil, err
 	}
 
+	// No idempotency key to check
+	key := payParams.IdempotencyKey
+	if len(key) == 0 || c.idempotencyStore == nil {
+		paymentResponse, _, payErr := c.pay(ctx, authToken, payParams)
+		return paymentResponse, payErr
+	}
+
+	// Only one payment per idempotency key at a time
+	unlock := c.idempotencyKeys.lock(key)
+	defer unlock()
+
+	// Hash the parameters (a repeated key must be used for the same payment)
+	var paramsHash string
+	if paramsHash, err = hashPayParameters(payParams); err != nil {
+		return nil, fmt.Errorf("failed to hash payment parameters: %w", err)
+	}
+
+	// Return the original payment (if found)
+	var record *IdempotencyRecord
+	if record, err = c.idempotencyStore.Get(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	} else if record != nil {
+		if record.ParamsHash != paramsHash {
+			return nil, ErrIdempotencyKeyReused
+		} else if record.Payment == nil {
+			return nil, ErrPaymentOutcomeUnknown
+		}
+		setPaymentAttributes(span, record.Payment)
+		return record.Payment, nil
+	}
+
+	// Record the key before paying (a payment with an unknown outcome is never sent again)
+	if err = c.idempotencyStore.Set(ctx, key, &IdempotencyRecord{ParamsHash: paramsHash}); err != nil {
+		return nil, fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+
+	// Make the payment
+	var paymentResponse *PaymentResponse
+	var sent bool
+	if paymentResponse, sent, err = c.pay(ctx, authToken, payParams); err != nil {
+
+		// The payment may have been made (keep the key)
+		if sent && !isPaymentRejected(err) {
+			return nil, fmt.Errorf("%w: %w", ErrPaymentOutcomeUnknown, err)
+		}
+
+		// The payment was not made (release the key)
+		if deleteErr := c.idempotencyStore.Delete(ctx, key); deleteErr != nil {
+			return nil, fmt.Errorf("%w (failed to delete idempotency key: %s)", err, deleteErr.Error())
+		}
+		return nil, err
+	}
+
+	// Store the payment
+	if err = c.idempotencyStore.Set(ctx, key, &IdempotencyRecord{
+		ParamsHash: paramsHash,
+		Payment:    paymentResponse,
+	}); err != nil {
+		return paymentResponse, fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+	return paymentResponse, nil
+}
+
+// isPaymentRejected will return true if HandCash definitely rejected the payment
+// (a 4xx response, except a request timeout)
+func isPaymentRejected(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		apiErr.StatusCode >= http.StatusBadRequest &&
+		apiErr.StatusCode < http.StatusInternalServerError &&
+		apiErr.StatusCode != http.StatusRequestTimeout
+}
+
+// pay will fire the payment request (sent is false if the request was never sent)
+func (c *Client) pay(ctx context.Context, authToken string,
+	payParams *PayParameters) (_ *PaymentResponse, sent bool, err error) {
+
 	// Get the signed request
-	signed, err := c.getSignedRequest(
+	var signed *signedRequest
+	if signed, err = c.getSignedRequest(
 		http.MethodPost,
 		endpointGetPayRequest,
 		authToken,
 		payParams,
 		currentISOTimestamp(),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("error creating signed request: %w", err)
+	); err != nil {
+		return nil, false, fmt.Errorf("error creating signed request: %w", err)
 	}
 
 	// Convert into bytes
 	var payParamsBytes []byte
 	if payParamsBytes, err = json.Marshal(payParams); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	// Make the HTTP request
@@ -81,22 +176,28 @@ func (c *Client) Pay(ctx context.Context, authToken string,
 			Data:           payParamsBytes,
 			ExpectedStatus: http.StatusOK,
 			Method:         signed.Method,
+			NoRetry:        true,
 			URL:            signed.URI,
 		},
 		signed,
 	)
 
 	// Error in request?
+	sent = response.Attempts > 0
 	if response.Error != nil {
-		return nil, response.Error
+		return nil, sent, response.Error
 	}
 
 	// Unmarshal pay response
 	paymentResponse := new(PaymentResponse)
 	if err = json.Unmarshal(response.BodyContents, &paymentResponse); err != nil {
-		return nil, fmt.Errorf("failed unmarshal: %w", err)
+		return nil, sent, fmt.Errorf("failed unmarshal: %w", err)
 	} else if paymentResponse == nil || paymentResponse.TransactionID == "" {
-		return nil, fmt.Errorf("failed to make payment")
+		return nil, sent, fmt.Errorf("failed to make payment")
 	}
-	return paymentResponse, nil
+	setPaymentAttributes(trace.SpanFromContext(ctx), paymentResponse)
+	if c.Options.MetricsCollector != nil {
+		c.Options.MetricsCollector.AddSatoshisPaid(paymentResponse.SatoshiAmount)
+	}
+	return paymentResponse, sent, nil
 }
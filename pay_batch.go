@@ -0,0 +1,158 @@
+package handcash
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// PayBatch pays many receivers by splitting them into multiple payments
+//
+// Every receiver is validated first: an invalid receiver gets its ValidationErrors (with
+// the path in the receivers slice, IE: receivers[3].amount) and is not sent. The valid
+// receivers are paid concurrently (PayBatchOptions.Concurrency) and a failed payment
+// does not stop the others: the report has the transaction ID or error for every receiver.
+// An error is only returned if the batch could not be started.
+func (c *Client) PayBatch(ctx context.Context, authToken string, receivers []*Payment,
+	opts *PayBatchOptions) (_ *PayBatchReport, err error) {
+
+	// Start the span
+	ctx, span := c.startSpan(ctx, "PayBatch")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// Make sure we have an auth token and receivers
+	if len(authToken) == 0 {
+		return nil, fmt.Errorf("missing auth token")
+	} else if len(receivers) == 0 {
+		return nil, fmt.Errorf("missing receivers")
+	}
+
+	// Set the options
+	if opts == nil {
+		opts = new(PayBatchOptions)
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultPayBatchConcurrency
+	}
+	perPayment := opts.ReceiversPerPayment
+	if perPayment <= 0 || perPayment > maxReceiversPerPayment {
+		perPayment = maxReceiversPerPayment
+	}
+
+	// Check the details shared by every payment
+	if errs := validatePaymentDetails(opts.Description, opts.Attachment); len(errs) > 0 {
+		return nil, errs
+	}
+
+	// Start the report and split the receivers into chunks (only valid receivers are paid)
+	//
+	// Chunks are taken from the positions in the receivers slice (not from the valid
+	// receivers only), so a chunk keeps its index and idempotency key when the batch is
+	// run again after fixing an invalid receiver
+	report := &PayBatchReport{Results: make([]*PayBatchResult, len(receivers))}
+	chunks := make([]*batchChunk, 0, (len(receivers)+perPayment-1)/perPayment)
+	for i, receiver := range receivers {
+		if i%perPayment == 0 {
+			chunks = append(chunks, &batchChunk{index: i / perPayment})
+		}
+		report.Results[i] = &PayBatchResult{Receiver: receiver}
+		if errs := validateReceiver(fmt.Sprintf("receivers[%d]", i), receiver); len(errs) > 0 {
+			report.Results[i].Error = errs
+			continue
+		}
+		chunk := chunks[len(chunks)-1]
+		chunk.receivers = append(chunk.receivers, receiver)
+		chunk.results = append(chunk.results, report.Results[i])
+	}
+
+	// Make the payments (in chunks)
+	payments := make([]*PaymentResponse, len(chunks))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		if len(chunk.receivers) == 0 {
+			continue
+		}
+
+		// Wait for a free slot (or stop if the context is done)
+		select {
+		case <-ctx.Done():
+		case semaphore <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			for _, remaining := range chunks[i:] {
+				setBatchResults(remaining.results, nil, ctx.Err())
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(chunk *batchChunk) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			params := &PayParameters{
+				AppAction:   opts.AppAction,
+				Attachment:  opts.Attachment,
+				Description: opts.Description,
+				Receivers:   chunk.receivers,
+			}
+			if len(opts.IdempotencyKey) > 0 {
+				params.IdempotencyKey = fmt.Sprintf("%s-%d", opts.IdempotencyKey, chunk.index)
+			}
+
+			payment, payErr := c.Pay(ctx, authToken, params)
+			payments[chunk.index] = payment
+			setBatchResults(chunk.results, payment, payErr)
+		}(chunk)
+	}
+	wg.Wait()
+
+	return report.withPayments(payments), nil
+}
+
+// batchChunk is the valid receivers of a chunk of the receivers slice (paid together)
+type batchChunk struct {
+	index     int
+	receivers []*Payment
+	results   []*PayBatchResult
+}
+
+// Failed will return the results of the receivers that were not paid
+func (r *PayBatchReport) Failed() (failed []*PayBatchResult) {
+	for _, result := range r.Results {
+		if result.Error != nil {
+			failed = append(failed, result)
+		}
+	}
+	return
+}
+
+// withPayments will set the successful payments on the report
+func (r *PayBatchReport) withPayments(payments []*PaymentResponse) *PayBatchReport {
+	for _, payment := range payments {
+		if payment != nil {
+			r.Payments = append(r.Payments, payment)
+		}
+	}
+	return r
+}
+
+// setBatchResults will set the payment result for every receiver in the chunk
+//
+// A payment with a transaction ID was made, even if an error was returned with it
+// (IE: the idempotency key could not be stored), so the receivers are never marked as failed
+func setBatchResults(results []*PayBatchResult, payment *PaymentResponse, err error) {
+	for _, result := range results {
+		if payment != nil && len(payment.TransactionID) > 0 {
+			result.TransactionID = payment.TransactionID
+			continue
+		}
+		result.Error = err
+	}
+}
@@ -0,0 +1,285 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHTTPPayBatch for mocking batch payments (a payment to a receiver starting with "fail" fails)
+type mockHTTPPayBatch struct {
+	mu       sync.Mutex
+	payments []*PayParameters
+}
+
+// Do is a mock http request
+func (m *mockHTTPPayBatch) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	// Read the payment
+	params := new(PayParameters)
+	body, _ := ioutil.ReadAll(req.Body)
+	if err := json.Unmarshal(body, params); err != nil {
+		return resp, err
+	}
+
+	m.mu.Lock()
+	m.payments = append(m.payments, params)
+	index := len(m.payments)
+	m.mu.Unlock()
+
+	for _, receiver := range params.Receivers {
+		if strings.HasPrefix(receiver.To, "fail") {
+			resp.StatusCode = http.StatusBadRequest
+			resp.Body = ioutil.NopCloser(bytes.NewBufferString(`{"message":"invalid receiver"}`))
+			return resp, nil
+		}
+	}
+
+	resp.StatusCode = http.StatusOK
+	resp.Body = ioutil.NopCloser(bytes.NewBufferString(fmt.Sprintf(
+		`{"transactionId":"tx-%d","satoshiAmount":%d}`, index, 100*len(params.Receivers),
+	)))
+	return resp, nil
+}
+
+// paymentStoreFailure fails to store the payments (the in-flight records are stored)
+type paymentStoreFailure struct {
+	*MemoryIdempotencyStore
+}
+
+// Set will fail for a record with a payment
+func (s *paymentStoreFailure) Set(ctx context.Context, key string, record *IdempotencyRecord) error {
+	if record.Payment != nil {
+		return fmt.Errorf("store is down")
+	}
+	return s.MemoryIdempotencyStore.Set(ctx, key, record)
+}
+
+// newTestReceivers returns receivers named with the prefix and index
+func newTestReceivers(prefix string, count int) []*Payment {
+	receivers := make([]*Payment, count)
+	for i := range receivers {
+		receivers[i] = &Payment{Amount: 0.01, CurrencyCode: CurrencyUSD, To: fmt.Sprintf("%s%d", prefix, i)}
+	}
+	return receivers
+}
+
+func TestClient_PayBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPPayBatch{}, EnvironmentBeta)
+		report, err := client.PayBatch(context.Background(), "", newTestReceivers("user", 1), nil)
+		assert.Error(t, err)
+		assert.Nil(t, report)
+	})
+
+	t.Run("missing receivers", func(t *testing.T) {
+		client := newTestClient(&mockHTTPPayBatch{}, EnvironmentBeta)
+		report, err := client.PayBatch(context.Background(), "000000", nil, nil)
+		assert.Error(t, err)
+		assert.Nil(t, report)
+
+	})
+
+	t.Run("invalid details", func(t *testing.T) {
+		mock := &mockHTTPPayBatch{}
+		client := newTestClient(mock, EnvironmentBeta)
+		report, err := client.PayBatch(context.Background(), "000000", newTestReceivers("user", 2), &PayBatchOptions{
+			Attachment: &Attachment{Format: AttachmentFormatHex, Value: "zz"},
+		})
+		var errs ValidationErrors
+		require.ErrorAs(t, err, &errs)
+		assert.Equal(t, "attachment.value", errs[0].Field)
+		assert.Nil(t, report)
+		assert.Equal(t, 0, len(mock.payments))
+	})
+
+	t.Run("invalid receivers are not sent", func(t *testing.T) {
+		mock := &mockHTTPPayBatch{}
+		client := newTestClient(mock, EnvironmentBeta)
+		receivers := newTestReceivers("user", 5)
+		receivers[1] = nil
+		receivers[3].Amount = 0
+
+		report, err := client.PayBatch(context.Background(), "000000", receivers, &PayBatchOptions{
+			ReceiversPerPayment: 2,
+		})
+		require.NoError(t, err)
+		// The chunks keep the positions of the receivers (IE: [0, 1], [2, 3], [4])
+		require.Equal(t, 3, len(mock.payments))
+		for _, payment := range mock.payments {
+			assert.Equal(t, 1, len(payment.Receivers))
+		}
+
+		failed := report.Failed()
+		require.Equal(t, 2, len(failed))
+		var errs ValidationErrors
+		require.ErrorAs(t, report.Results[1].Error, &errs)
+		assert.Equal(t, "receivers[1]", errs[0].Field)
+		require.ErrorAs(t, report.Results[3].Error, &errs)
+		assert.Equal(t, "receivers[3].amount", errs[0].Field)
+		for _, i := range []int{0, 2, 4} {
+			assert.NoError(t, report.Results[i].Error)
+			assert.NotEmpty(t, report.Results[i].TransactionID)
+		}
+	})
+
+	t.Run("receivers are split into payments", func(t *testing.T) {
+		mock := &mockHTTPPayBatch{}
+		client := newTestClient(mock, EnvironmentBeta)
+		receivers := newTestReceivers("user", 250)
+
+		report, err := client.PayBatch(context.Background(), "000000", receivers, &PayBatchOptions{
+			AppAction:   AppActionTipGroup,
+			Description: "payout",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, report)
+
+		require.Equal(t, 3, len(mock.payments))
+		sizes := make(map[int]int)
+		for _, payment := range mock.payments {
+			sizes[len(payment.Receivers)]++
+			assert.Equal(t, AppActionTipGroup, payment.AppAction)
+			assert.Equal(t, "payout", payment.Description)
+		}
+		assert.Equal(t, map[int]int{maxReceiversPerPayment: 2, 50: 1}, sizes)
+
+		assert.Equal(t, 3, len(report.Payments))
+		assert.Equal(t, 0, len(report.Failed()))
+		require.Equal(t, len(receivers), len(report.Results))
+		for i, result := range report.Results {
+			assert.Equal(t, receivers[i], result.Receiver)
+			assert.NotEmpty(t, result.TransactionID)
+		}
+		assert.Equal(t, report.Results[0].TransactionID, report.Results[99].TransactionID)
+		assert.NotEqual(t, report.Results[99].TransactionID, report.Results[100].TransactionID)
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		mock := &mockHTTPPayBatch{}
+		client := newTestClient(mock, EnvironmentBeta)
+		receivers := append(newTestReceivers("user", 4), newTestReceivers("fail", 2)...)
+
+		report, err := client.PayBatch(context.Background(), "000000", receivers, &PayBatchOptions{
+			Concurrency:         2,
+			ReceiversPerPayment: 2,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, len(mock.payments))
+		assert.Equal(t, 2, len(report.Payments))
+
+		failed := report.Failed()
+		require.Equal(t, 2, len(failed))
+		for _, result := range failed {
+			assert.True(t, strings.HasPrefix(result.Receiver.To, "fail"))
+			assert.Equal(t, "invalid receiver", result.Error.Error())
+			assert.Empty(t, result.TransactionID)
+		}
+	})
+
+	t.Run("idempotency keys per payment", func(t *testing.T) {
+		mock := &mockHTTPPayBatch{}
+		client := newTestClient(mock, EnvironmentBeta)
+		receivers := newTestReceivers("user", 4)
+		opts := &PayBatchOptions{IdempotencyKey: "payout", ReceiversPerPayment: 2}
+
+		first, err := client.PayBatch(context.Background(), "000000", receivers, opts)
+		require.NoError(t, err)
+
+		var second *PayBatchReport
+		second, err = client.PayBatch(context.Background(), "000000", receivers, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 2, len(mock.payments))
+		assert.Equal(t, first.Results[3].TransactionID, second.Results[3].TransactionID)
+	})
+
+	t.Run("fixed receiver keeps the idempotency keys", func(t *testing.T) {
+		mock := &mockHTTPPayBatch{}
+		client := newTestClient(mock, EnvironmentBeta)
+		opts := &PayBatchOptions{IdempotencyKey: "payout", ReceiversPerPayment: 1}
+
+		receivers := []*Payment{
+			{Amount: 0.01, CurrencyCode: CurrencyUSD, To: "bad handle!"},
+			{Amount: 0.01, CurrencyCode: CurrencyUSD, To: "bob"},
+		}
+		first, err := client.PayBatch(context.Background(), "000000", receivers, opts)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(mock.payments))
+		bobTransactionID := first.Results[1].TransactionID
+		require.NotEmpty(t, bobTransactionID)
+
+		// Run again with the invalid receiver fixed
+		receivers[0] = &Payment{Amount: 0.01, CurrencyCode: CurrencyUSD, To: "alice"}
+		var second *PayBatchReport
+		second, err = client.PayBatch(context.Background(), "000000", receivers, opts)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(mock.payments))
+		assert.Equal(t, "alice", mock.payments[1].Receivers[0].To)
+		assert.NotEmpty(t, second.Results[0].TransactionID)
+		assert.NotEqual(t, bobTransactionID, second.Results[0].TransactionID)
+		assert.Equal(t, bobTransactionID, second.Results[1].TransactionID)
+		assert.Empty(t, second.Failed())
+	})
+
+	t.Run("changed chunk with the same key is not paid", func(t *testing.T) {
+		mock := &mockHTTPPayBatch{}
+		client := newTestClient(mock, EnvironmentBeta)
+		opts := &PayBatchOptions{IdempotencyKey: "payout", ReceiversPerPayment: 2}
+
+		receivers := newTestReceivers("user", 2)
+		_, err := client.PayBatch(context.Background(), "000000", receivers, opts)
+		require.NoError(t, err)
+
+		receivers[1] = &Payment{Amount: 0.01, CurrencyCode: CurrencyUSD, To: "other"}
+		var report *PayBatchReport
+		report, err = client.PayBatch(context.Background(), "000000", receivers, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(mock.payments))
+		require.Equal(t, 2, len(report.Failed()))
+		assert.ErrorIs(t, report.Results[0].Error, ErrIdempotencyKeyReused)
+	})
+
+	t.Run("payment made is not reported as failed", func(t *testing.T) {
+		mock := &mockHTTPPayBatch{}
+		client := newTestClient(mock, EnvironmentBeta)
+		client.idempotencyStore = &paymentStoreFailure{NewMemoryIdempotencyStore(0)}
+
+		report, err := client.PayBatch(context.Background(), "000000", newTestReceivers("user", 2),
+			&PayBatchOptions{IdempotencyKey: "payout"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(mock.payments))
+		assert.Empty(t, report.Failed())
+		assert.NotEmpty(t, report.Results[0].TransactionID)
+		assert.Equal(t, 1, len(report.Payments))
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		mock := &mockHTTPPayBatch{}
+		client := newTestClient(mock, EnvironmentBeta)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		report, err := client.PayBatch(ctx, "000000", newTestReceivers("user", 3), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, len(mock.payments))
+		assert.Equal(t, 3, len(report.Failed()))
+		assert.ErrorIs(t, report.Results[0].Error, context.Canceled)
+	})
+}
@@ -0,0 +1,110 @@
+package handcash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+/*
+{
+  "items": [
+    "USER_PUBLIC_PROFILE",
+    "USER_PRIVATE_PROFILE",
+    "FRIENDS",
+    "PAY",
+    "DECRYPT",
+    "SIGN_DATA"
+  ]
+}
+*/
+
+// GetPermissions will get the permissions granted to the associated auth token
+//
+// Specs: https://github.com/HandCash/handcash-connect-sdk-js/blob/master/src/profile/index.js
+func (c *Client) GetPermissions(ctx context.Context, authToken string) (_ PermissionSet, err error) {
+
+	// Start the span
+	ctx, span := c.startSpan(ctx, "GetPermissions")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// Make sure we have an auth token
+	if len(authToken) == 0 {
+		return nil, fmt.Errorf("missing auth token")
+	}
+
+	// Get the signed request
+	signed, err := c.getSignedRequest(
+		http.MethodGet,
+		endpointGetPermissions,
+		authToken,
+		&requestBody{authToken: authToken},
+		currentISOTimestamp(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating signed request: %w", err)
+	}
+
+	// Make the HTTP request
+	response := httpRequest(
+		ctx,
+		c,
+		&httpPayload{
+			Data:           []byte(emptyBody),
+			ExpectedStatus: http.StatusOK,
+			Method:         signed.Method,
+			URL:            signed.URI,
+		},
+		signed,
+	)
+
+	// Error in request?
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	// Unmarshal into the permissions
+	permissions := new(permissionsResponse)
+	if err = json.Unmarshal(response.BodyContents, &permissions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal: %w", err)
+	} else if permissions == nil || permissions.Items == nil {
+		return nil, fmt.Errorf("failed to find permissions")
+	}
+	return permissions.Items, nil
+}
+
+// Has will return true if all the given permissions are in the set
+func (p PermissionSet) Has(permissions ...Permission) bool {
+	return len(p.missing(permissions)) == 0
+}
+
+// Require will return an error listing any of the given permissions that are not in the set
+//
+// Useful for checking scopes before calling Pay() or GetProfile()
+func (p PermissionSet) Require(permissions ...Permission) error {
+	if missing := p.missing(permissions); len(missing) > 0 {
+		return fmt.Errorf("missing permission: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// missing will return the permissions that are not in the set
+func (p PermissionSet) missing(permissions []Permission) (missing []string) {
+	for _, permission := range permissions {
+		found := false
+		for _, granted := range p {
+			if granted == permission {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, string(permission))
+		}
+	}
+	return
+}
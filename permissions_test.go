@@ -0,0 +1,134 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockHTTPGetPermissions for mocking requests
+type mockHTTPGetPermissions struct{}
+
+// Do is a mock http request
+func (m *mockHTTPGetPermissions) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	// Beta
+	if req.URL.String() == environments[EnvironmentBeta].APIURL+endpointGetPermissions {
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"items":["USER_PUBLIC_PROFILE","FRIENDS","PAY"]}`)))
+	}
+
+	// Default is valid
+	return resp, nil
+}
+
+// mockHTTPInvalidPermissionsData for mocking requests
+type mockHTTPInvalidPermissionsData struct{}
+
+// Do is a mock http request
+func (m *mockHTTPInvalidPermissionsData) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	resp.StatusCode = http.StatusOK
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"invalid":"permissions"}`)))
+
+	// Default is valid
+	return resp, nil
+}
+
+func TestClient_GetPermissions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPermissions{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		permissions, err := client.GetPermissions(context.Background(), "")
+		assert.Error(t, err)
+		assert.Nil(t, permissions)
+	})
+
+	t.Run("invalid auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPermissions{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		permissions, err := client.GetPermissions(context.Background(), "0")
+		assert.Error(t, err)
+		assert.Nil(t, permissions)
+	})
+
+	t.Run("valid auth token (beta)", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPermissions{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		permissions, err := client.GetPermissions(context.Background(), "000000")
+		assert.NoError(t, err)
+		assert.Equal(t, PermissionSet{PermissionUserPublicProfile, PermissionFriends, PermissionPay}, permissions)
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		client := newTestClient(&mockHTTPBadRequest{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		permissions, err := client.GetPermissions(context.Background(), "000000")
+		assert.Error(t, err)
+		assert.Nil(t, permissions)
+	})
+
+	t.Run("invalid permissions data", func(t *testing.T) {
+		client := newTestClient(&mockHTTPInvalidPermissionsData{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		permissions, err := client.GetPermissions(context.Background(), "000000")
+		assert.Error(t, err)
+		assert.Nil(t, permissions)
+	})
+}
+
+func TestPermissionSet_Has(t *testing.T) {
+	t.Parallel()
+
+	permissions := PermissionSet{PermissionUserPublicProfile, PermissionPay}
+
+	t.Run("has permission", func(t *testing.T) {
+		assert.True(t, permissions.Has(PermissionPay))
+		assert.True(t, permissions.Has(PermissionPay, PermissionUserPublicProfile))
+	})
+
+	t.Run("missing permission", func(t *testing.T) {
+		assert.False(t, permissions.Has(PermissionSignData))
+		assert.False(t, permissions.Has(PermissionPay, PermissionUserPrivateProfile))
+	})
+
+	t.Run("empty set", func(t *testing.T) {
+		assert.False(t, PermissionSet{}.Has(PermissionPay))
+		assert.True(t, PermissionSet{}.Has())
+	})
+}
+
+func TestPermissionSet_Require(t *testing.T) {
+	t.Parallel()
+
+	permissions := PermissionSet{PermissionUserPublicProfile, PermissionPay}
+
+	t.Run("has permissions", func(t *testing.T) {
+		assert.NoError(t, permissions.Require(PermissionPay, PermissionUserPublicProfile))
+	})
+
+	t.Run("missing permissions", func(t *testing.T) {
+		err := permissions.Require(PermissionPay, PermissionUserPrivateProfile, PermissionDecrypt)
+		assert.Error(t, err)
+		assert.Equal(t, "missing permission: USER_PRIVATE_PROFILE, DECRYPT", err.Error())
+	})
+}
@@ -28,7 +28,13 @@ import (
 // GetProfile will get the profile for the associated auth token
 //
 // Specs: https://github.com/HandCash/handcash-connect-sdk-js/blob/master/src/profile/index.js
-func (c *Client) GetProfile(ctx context.Context, token string) (*Profile, error) {
+func (c *Client) GetProfile(ctx context.Context, token string) (_ *Profile, err error) {
+
+	// Start the span
+	ctx, span := c.startSpan(ctx, "GetProfile")
+	defer func() {
+		endSpan(span, err)
+	}()
 
 	// Make sure we have an auth token
 	if len(token) == 0 {
@@ -0,0 +1,125 @@
+package handcash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+/*
+{
+  "items": [
+    {
+      "id": "5f70dd4beea0c1002f6cbb2e",
+      "handle": "MisterZ",
+      "paymail": "MisterZ@beta.handcash.io",
+      "displayName": "MrZ",
+      "avatarUrl": "https://beta-cloud.handcash.io/users/profilePicture/MisterZ",
+      "localCurrencyCode": "USD"
+    }
+  ]
+}
+*/
+
+// GetPublicProfilesByHandle will get the public profiles for the given list of handles
+//
+// Large lists are split into multiple requests. The returned slice is in the same
+// order as the handles given, and a handle that was not found is marked with a nil entry.
+//
+// Specs: https://github.com/HandCash/handcash-connect-sdk-js/blob/master/src/profile/index.js
+func (c *Client) GetPublicProfilesByHandle(ctx context.Context, authToken string,
+	handles []string) (_ []*PublicProfile, err error) {
+
+	// Start the span
+	ctx, span := c.startSpan(ctx, "GetPublicProfilesByHandle")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// Make sure we have an auth token
+	if len(authToken) == 0 {
+		return nil, fmt.Errorf("missing auth token")
+	} else if len(handles) == 0 {
+		return nil, fmt.Errorf("missing handles")
+	}
+
+	// Fetch the profiles in chunks
+	found := make(map[string]*PublicProfile, len(handles))
+	for start := 0; start < len(handles); start += maxHandlesPerRequest {
+		end := start + maxHandlesPerRequest
+		if end > len(handles) {
+			end = len(handles)
+		}
+
+		profiles, err := c.getPublicProfiles(ctx, authToken, handles[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, profile := range profiles {
+			if profile != nil {
+				found[strings.ToLower(profile.Handle)] = profile
+			}
+		}
+	}
+
+	// Return the profiles in the same order as the handles
+	results := make([]*PublicProfile, len(handles))
+	for i, handle := range handles {
+		results[i] = found[strings.ToLower(handle)]
+	}
+	return results, nil
+}
+
+// getPublicProfiles will fire a single public profiles request for the given handles
+func (c *Client) getPublicProfiles(ctx context.Context, authToken string,
+	handles []string) ([]*PublicProfile, error) {
+
+	// Get the signed request
+	signed, err := c.getSignedRequest(
+		http.MethodGet,
+		endpointPublicProfilesByHandle,
+		authToken,
+		&PublicProfilesRequest{Aliases: handles},
+		currentISOTimestamp(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating signed request: %w", err)
+	}
+
+	// Convert into bytes
+	var params []byte
+	if params, err = json.Marshal(
+		&PublicProfilesRequest{Aliases: handles},
+	); err != nil {
+		return nil, err
+	}
+
+	// Make the HTTP request
+	response := httpRequest(
+		ctx,
+		c,
+		&httpPayload{
+			Data:           params,
+			ExpectedStatus: http.StatusOK,
+			Method:         signed.Method,
+			URL:            signed.URI,
+		},
+		signed,
+	)
+
+	// Error in request?
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	// Unmarshal into the profiles
+	profiles := new(publicProfilesResponse)
+	if err = json.Unmarshal(response.BodyContents, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal: %w", err)
+	} else if profiles == nil || profiles.Items == nil {
+		return nil, fmt.Errorf("failed to find profiles")
+	}
+	return profiles.Items, nil
+}
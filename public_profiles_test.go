@@ -0,0 +1,156 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockHTTPGetPublicProfiles for mocking requests
+type mockHTTPGetPublicProfiles struct {
+	requests int
+}
+
+// Do is a mock http request
+func (m *mockHTTPGetPublicProfiles) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	// Beta
+	if req.URL.String() == environments[EnvironmentBeta].APIURL+endpointPublicProfilesByHandle {
+		m.requests++
+
+		// Return a profile for every handle except "unknown"
+		params := new(PublicProfilesRequest)
+		if err := json.NewDecoder(req.Body).Decode(params); err != nil {
+			return resp, err
+		}
+		profiles := &publicProfilesResponse{Items: []*PublicProfile{}}
+		for _, handle := range params.Aliases {
+			if handle == "unknown" {
+				continue
+			}
+			profiles.Items = append(profiles.Items, &PublicProfile{
+				AvatarURL:         "https://beta-cloud.handcash.io/users/profilePicture/" + handle,
+				Handle:            strings.ToLower(handle),
+				ID:                "id-" + strings.ToLower(handle),
+				LocalCurrencyCode: CurrencyUSD,
+				Paymail:           handle + "@beta.handcash.io",
+			})
+		}
+		body, _ := json.Marshal(profiles)
+
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	}
+
+	// Default is valid
+	return resp, nil
+}
+
+// mockHTTPInvalidPublicProfilesData for mocking requests
+type mockHTTPInvalidPublicProfilesData struct{}
+
+// Do is a mock http request
+func (m *mockHTTPInvalidPublicProfilesData) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	resp.StatusCode = http.StatusOK
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"invalid":"profiles"}`)))
+
+	// Default is valid
+	return resp, nil
+}
+
+func TestClient_GetPublicProfilesByHandle(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPublicProfiles{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		profiles, err := client.GetPublicProfilesByHandle(context.Background(), "", []string{"MisterZ"})
+		assert.Error(t, err)
+		assert.Nil(t, profiles)
+	})
+
+	t.Run("missing handles", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPublicProfiles{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		profiles, err := client.GetPublicProfilesByHandle(context.Background(), "000000", nil)
+		assert.Error(t, err)
+		assert.Nil(t, profiles)
+	})
+
+	t.Run("invalid auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPublicProfiles{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		profiles, err := client.GetPublicProfilesByHandle(context.Background(), "0", []string{"MisterZ"})
+		assert.Error(t, err)
+		assert.Nil(t, profiles)
+	})
+
+	t.Run("valid profiles in order", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPublicProfiles{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		profiles, err := client.GetPublicProfilesByHandle(
+			context.Background(), "000000", []string{"MisterZ", "unknown", "rjseibane"},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(profiles))
+		assert.Equal(t, "id-misterz", profiles[0].ID)
+		assert.Equal(t, "MisterZ@beta.handcash.io", profiles[0].Paymail)
+		assert.Nil(t, profiles[1])
+		assert.Equal(t, "id-rjseibane", profiles[2].ID)
+	})
+
+	t.Run("large list is chunked", func(t *testing.T) {
+		mock := &mockHTTPGetPublicProfiles{}
+		client := newTestClient(mock, EnvironmentBeta)
+		assert.NotNil(t, client)
+
+		handles := make([]string, (maxHandlesPerRequest*2)+1)
+		for i := range handles {
+			handles[i] = fmt.Sprintf("handle%d", i)
+		}
+
+		profiles, err := client.GetPublicProfilesByHandle(context.Background(), "000000", handles)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, mock.requests)
+		assert.Equal(t, len(handles), len(profiles))
+		for i, profile := range profiles {
+			assert.Equal(t, handles[i], profile.Handle)
+		}
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		client := newTestClient(&mockHTTPBadRequest{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		profiles, err := client.GetPublicProfilesByHandle(context.Background(), "000000", []string{"MisterZ"})
+		assert.Error(t, err)
+		assert.Nil(t, profiles)
+	})
+
+	t.Run("invalid profiles data", func(t *testing.T) {
+		client := newTestClient(&mockHTTPInvalidPublicProfilesData{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		profiles, err := client.GetPublicProfilesByHandle(context.Background(), "000000", []string{"MisterZ"})
+		assert.Error(t, err)
+		assert.Nil(t, profiles)
+	})
+}
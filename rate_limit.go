@@ -0,0 +1,177 @@
+package handcash
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket limit (a zero rate is unlimited)
+type RateLimit struct {
+	Burst int     `json:"burst"` // Maximum requests at once (at least 1)
+	Rate  float64 `json:"rate"`  // Requests per second
+}
+
+// RateLimitOptions are the client-side rate limits for all requests
+//
+// Requests wait for both limits (the per-token limit is keyed by the public key
+// derived from the auth token) and give up if the context is done
+type RateLimitOptions struct {
+	Global   RateLimit `json:"global"`    // Limit for all requests made by the client
+	PerToken RateLimit `json:"per_token"` // Limit for the requests made with each auth token
+}
+
+// rateLimiter holds the global and per-token buckets
+type rateLimiter struct {
+	global   *tokenBucket
+	mu       sync.Mutex
+	options  RateLimitOptions
+	perToken map[string]*tokenBucket // Keyed by public key
+}
+
+// newRateLimiter will return a rate limiter for the options (nil if not limited)
+func newRateLimiter(options *RateLimitOptions) *rateLimiter {
+	if options == nil || (options.Global.Rate <= 0 && options.PerToken.Rate <= 0) {
+		return nil
+	}
+	return &rateLimiter{
+		global:   newTokenBucket(options.Global),
+		options:  *options,
+		perToken: make(map[string]*tokenBucket),
+	}
+}
+
+// wait will block until the request is allowed by both limits (or the context is done)
+//
+// The per-token limit is waited for first, so a request queued behind its own limit
+// does not hold global capacity (and its per-token token is returned if the global
+// wait fails)
+func (r *rateLimiter) wait(ctx context.Context, publicKey string) error {
+	if r == nil {
+		return nil
+	}
+	bucket := r.bucket(publicKey)
+	if err := bucket.wait(ctx); err != nil {
+		return err
+	}
+	if err := r.global.wait(ctx); err != nil {
+		if bucket != nil {
+			bucket.cancel()
+		}
+		return err
+	}
+	return nil
+}
+
+// bucket will return the bucket for the public key (idle buckets are removed)
+func (r *rateLimiter) bucket(publicKey string) *tokenBucket {
+	if r.options.PerToken.Rate <= 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket, ok := r.perToken[publicKey]
+	if !ok {
+		now := time.Now()
+		for key, b := range r.perToken {
+			if b.idle(now) {
+				delete(r.perToken, key)
+			}
+		}
+		bucket = newTokenBucket(r.options.PerToken)
+		r.perToken[publicKey] = bucket
+	}
+	return bucket
+}
+
+// tokenBucket is a single token bucket
+type tokenBucket struct {
+	burst  float64
+	last   time.Time
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+}
+
+// newTokenBucket will return a full bucket for the limit (nil if not limited)
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.Rate <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{burst: burst, last: time.Now(), rate: limit.Rate, tokens: burst}
+}
+
+// reserve will take a token and return how long to wait until it is available
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel will return a reserved token that was not used
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// idle will return true if the bucket is full (and can be removed)
+func (b *tokenBucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// refill will add the tokens earned since the last refill
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// wait will block until a token is available (or the context is done)
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	delay := b.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
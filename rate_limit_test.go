@@ -0,0 +1,148 @@
+package handcash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, newRateLimiter(nil))
+	assert.Nil(t, newRateLimiter(&RateLimitOptions{}))
+
+	limiter := newRateLimiter(&RateLimitOptions{PerToken: RateLimit{Rate: 1}})
+	require.NotNil(t, limiter)
+	assert.Nil(t, limiter.global)
+
+	// A nil limiter never blocks
+	var disabled *rateLimiter
+	assert.NoError(t, disabled.wait(context.Background(), "key"))
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+
+	t.Run("burst then wait", func(t *testing.T) {
+		bucket := newTokenBucket(RateLimit{Burst: 2, Rate: 20})
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, bucket.wait(context.Background()))
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	})
+
+	t.Run("minimum burst", func(t *testing.T) {
+		bucket := newTokenBucket(RateLimit{Rate: 1})
+		assert.Equal(t, float64(1), bucket.burst)
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		bucket := newTokenBucket(RateLimit{Burst: 1, Rate: 0.1})
+		require.NoError(t, bucket.wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := bucket.wait(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// The token was returned
+		assert.InDelta(t, 0, bucket.tokens, 0.01)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		bucket := newTokenBucket(RateLimit{Burst: 1, Rate: 1})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, bucket.wait(ctx), context.Canceled)
+		assert.Equal(t, float64(1), bucket.tokens)
+	})
+}
+
+func TestRateLimiter_PerToken(t *testing.T) {
+	t.Parallel()
+
+	t.Run("tokens are limited separately", func(t *testing.T) {
+		limiter := newRateLimiter(&RateLimitOptions{PerToken: RateLimit{Burst: 1, Rate: 0.1}})
+		require.NoError(t, limiter.wait(context.Background(), "key-1"))
+		require.NoError(t, limiter.wait(context.Background(), "key-2"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Error(t, limiter.wait(ctx, "key-1"))
+	})
+
+	t.Run("global limit is shared", func(t *testing.T) {
+		limiter := newRateLimiter(&RateLimitOptions{Global: RateLimit{Burst: 1, Rate: 0.1}})
+		require.NoError(t, limiter.wait(context.Background(), "key-1"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Error(t, limiter.wait(ctx, "key-2"))
+	})
+
+	t.Run("waiting for a token limit does not use the global limit", func(t *testing.T) {
+		limiter := newRateLimiter(&RateLimitOptions{
+			Global:   RateLimit{Burst: 2, Rate: 0.1},
+			PerToken: RateLimit{Burst: 1, Rate: 0.1},
+		})
+		require.NoError(t, limiter.wait(context.Background(), "key-1"))
+
+		// Queued behind its own limit (then canceled)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, limiter.wait(ctx, "key-1"), context.DeadlineExceeded)
+
+		// The global limit still has room for another token
+		ctx2, cancel2 := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel2()
+		assert.NoError(t, limiter.wait(ctx2, "key-2"))
+	})
+
+	t.Run("failed global wait returns the token", func(t *testing.T) {
+		limiter := newRateLimiter(&RateLimitOptions{
+			Global:   RateLimit{Burst: 1, Rate: 0.1},
+			PerToken: RateLimit{Burst: 1, Rate: 0.1},
+		})
+		require.NoError(t, limiter.wait(context.Background(), "key-1"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, limiter.wait(ctx, "key-2"), context.DeadlineExceeded)
+		assert.InDelta(t, 1, limiter.bucket("key-2").tokens, 0.01)
+	})
+
+	t.Run("idle buckets are removed", func(t *testing.T) {
+		limiter := newRateLimiter(&RateLimitOptions{PerToken: RateLimit{Burst: 1, Rate: 1000}})
+		require.NoError(t, limiter.wait(context.Background(), "key-1"))
+		time.Sleep(5 * time.Millisecond)
+		require.NoError(t, limiter.wait(context.Background(), "key-2"))
+		assert.Equal(t, 1, len(limiter.perToken))
+	})
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultClientOptions()
+	options.RateLimit = &RateLimitOptions{PerToken: RateLimit{Burst: 1, Rate: 20}}
+	client := NewClient(options, nil, EnvironmentBeta)
+	client.httpClient = &mockHTTPGetProfile{}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := client.GetProfile(context.Background(), "000000")
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	// Waiting respects the context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.GetProfile(ctx, "000000")
+	assert.ErrorIs(t, err, context.Canceled)
+}
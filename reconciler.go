@@ -0,0 +1,331 @@
+package handcash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultReconcileInterval is the default time between reconciliation passes
+const defaultReconcileInterval = 30 * time.Second
+
+// ErrReconcilerClosed is returned when the reconciler was already stopped (its events channel is closed)
+var ErrReconcilerClosed = errors.New("reconciler is closed")
+
+// ExpectedPayment is a payment waiting to be reconciled against GetPayment()
+//
+// Optional fields that are not set are not checked
+type ExpectedPayment struct {
+	Attachments    []*Attachment        `json:"attachments,omitempty"`    // Attachments sent with the payment
+	MaxSatoshiFees uint64               `json:"maxSatoshiFees,omitempty"` // Highest fees expected
+	Outputs        []*TransactionOutput `json:"outputs,omitempty"`        // Receiver outputs expected in the raw transaction
+	Receivers      []string             `json:"receivers,omitempty"`      // Handles or paymails of the participants
+	SatoshiAmount  uint64               `json:"satoshiAmount"`            // Amount paid
+	TransactionID  string               `json:"transactionId"`            // Transaction to reconcile
+}
+
+// PendingStore persists the payments waiting to be reconciled (so a restart resumes)
+type PendingStore interface {
+
+	// Add stores the pending payment (replacing any with the same transaction ID)
+	Add(ctx context.Context, payment *ExpectedPayment) error
+
+	// List returns all the pending payments
+	List(ctx context.Context) ([]*ExpectedPayment, error)
+
+	// Remove deletes the pending payment
+	Remove(ctx context.Context, transactionID string) error
+}
+
+// ReconcileEventType is the result of reconciling a payment
+type ReconcileEventType string
+
+// ReconcileEventType enum
+const (
+	ReconcileError    ReconcileEventType = "error"    // GetPayment or the store failed (the payments stay pending, Expected is nil for a failed pass)
+	ReconcileMatched  ReconcileEventType = "matched"  // The payment matches
+	ReconcileMismatch ReconcileEventType = "mismatch" // The payment does not match (see Mismatches)
+)
+
+// ReconcileEvent is emitted for every payment checked by the Reconciler
+type ReconcileEvent struct {
+	Error      error              `json:"-"`
+	Expected   *ExpectedPayment   `json:"expected"`
+	Mismatches []string           `json:"mismatches,omitempty"`
+	Payment    *PaymentResponse   `json:"payment,omitempty"`
+	Type       ReconcileEventType `json:"type"`
+}
+
+// ReconcilerOptions are the options for the Reconciler
+type ReconcilerOptions struct {
+	EventBuffer int           `json:"event_buffer"` // Size of the events channel buffer
+	Interval    time.Duration `json:"interval"`     // Time between passes (default: 30s)
+}
+
+// Reconciler polls GetPayment() for pending payments and reports if they match what was expected
+//
+// A reconciler uses a single auth token (the payer of the payments). Matched and
+// mismatched payments are removed from the store after their event is emitted (so an
+// event can be emitted again after a restart, but is never lost), and failed lookups are
+// retried on the next pass. Events must be read from Events(), or the reconciler will block.
+type Reconciler struct {
+	authToken string
+	client    *Client
+	closed    bool // Run has returned (events is closed)
+	events    chan *ReconcileEvent
+	interval  time.Duration
+	mu        sync.RWMutex // Held for reading while sending events (closing needs the write lock)
+	running   bool         // Run has been called
+	store     PendingStore
+}
+
+// NewReconciler will return a new reconciler (an in-memory store is used if the store is nil)
+func NewReconciler(client *Client, authToken string, store PendingStore, opts *ReconcilerOptions) *Reconciler {
+	if store == nil {
+		store = NewMemoryPendingStore()
+	}
+	if opts == nil {
+		opts = new(ReconcilerOptions)
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultReconcileInterval
+	}
+	return &Reconciler{
+		authToken: authToken,
+		client:    client,
+		events:    make(chan *ReconcileEvent, opts.EventBuffer),
+		interval:  interval,
+		store:     store,
+	}
+}
+
+// Add will add a payment to reconcile
+func (r *Reconciler) Add(ctx context.Context, payment *ExpectedPayment) error {
+	if payment == nil || len(payment.TransactionID) == 0 {
+		return fmt.Errorf("missing transaction id")
+	}
+	return r.store.Add(ctx, payment)
+}
+
+// Events will return the channel of reconcile events (closed when Run returns)
+func (r *Reconciler) Events() <-chan *ReconcileEvent {
+	return r.events
+}
+
+// Run will reconcile the pending payments every interval until the context is done
+//
+// A failed pass (IE: the store is unavailable) is emitted as a ReconcileError event and
+// retried on the next interval. Run can only be called once: the events channel is
+// closed when it returns
+func (r *Reconciler) Run(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return ErrReconcilerClosed
+	} else if r.running {
+		r.mu.Unlock()
+		return fmt.Errorf("reconciler is already running")
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.closed = true
+		close(r.events)
+	}()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Reconcile(ctx); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+
+			// Report the failed pass (and keep polling)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case r.events <- &ReconcileEvent{Error: err, Type: ReconcileError}:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile will make a single pass over the pending payments
+//
+// ErrReconcilerClosed is returned once Run has returned
+func (r *Reconciler) Reconcile(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return ErrReconcilerClosed
+	}
+
+	pending, err := r.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list pending payments: %w", err)
+	}
+
+	for _, expected := range pending {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		// Get the payment (failures stay pending)
+		event := &ReconcileEvent{Expected: expected}
+		if event.Payment, event.Error = r.client.GetPayment(ctx, r.authToken, expected.TransactionID); event.Error != nil {
+			event.Type = ReconcileError
+		} else {
+			event.Mismatches = reconcilePayment(expected, event.Payment)
+			event.Type = ReconcileMatched
+			if len(event.Mismatches) > 0 {
+				event.Type = ReconcileMismatch
+			}
+		}
+
+		// Emit the event (before removing the payment, so it is not lost)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case r.events <- event:
+		}
+
+		// The payment was checked (failures stay pending)
+		if event.Type != ReconcileError {
+			if err = r.store.Remove(ctx, expected.TransactionID); err != nil {
+				return fmt.Errorf("failed to remove pending payment: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// reconcilePayment will return the differences between the expected and actual payment
+func reconcilePayment(expected *ExpectedPayment, payment *PaymentResponse) (mismatches []string) {
+	if payment.SatoshiAmount != expected.SatoshiAmount {
+		mismatches = append(mismatches, fmt.Sprintf(
+			"satoshiAmount: expected %d, got %d", expected.SatoshiAmount, payment.SatoshiAmount,
+		))
+	}
+	if expected.MaxSatoshiFees > 0 && payment.SatoshiFees > expected.MaxSatoshiFees {
+		mismatches = append(mismatches, fmt.Sprintf(
+			"satoshiFees: expected at most %d, got %d", expected.MaxSatoshiFees, payment.SatoshiFees,
+		))
+	}
+
+	// Participants
+	if len(expected.Receivers) > 0 {
+		aliases := make([]string, 0, len(payment.Participants))
+		for _, participant := range payment.Participants {
+			if participant != nil {
+				aliases = append(aliases, participant.Alias)
+			}
+		}
+		if missing, unexpected := diffAliases(expected.Receivers, aliases); len(missing) > 0 || len(unexpected) > 0 {
+			mismatches = append(mismatches, fmt.Sprintf(
+				"participants: missing [%s], unexpected [%s]", strings.Join(missing, ", "), strings.Join(unexpected, ", "),
+			))
+		}
+	}
+
+	// Attachments
+	if expected.Attachments != nil {
+		expectedJSON, _ := json.Marshal(expected.Attachments)
+		actualJSON, _ := json.Marshal(payment.Attachments)
+		if !jsonEqual(expectedJSON, actualJSON) {
+			mismatches = append(mismatches, fmt.Sprintf("attachments: expected %s, got %s", expectedJSON, actualJSON))
+		}
+	}
+
+	// The raw transaction (if returned)
+	if len(payment.RawTransactionHex) > 0 {
+		var err error
+		if len(expected.Outputs) > 0 {
+			_, err = payment.VerifyTransaction(expected.Outputs, nil)
+		} else {
+			_, err = payment.verifyTransactionID()
+		}
+		if err != nil {
+			mismatches = append(mismatches, "transaction: "+err.Error())
+		}
+	}
+	return
+}
+
+// diffAliases will return the expected aliases that are missing and the unexpected
+// aliases (case-insensitive)
+func diffAliases(expected, actual []string) (missing, unexpected []string) {
+	actualSet := make(map[string]bool, len(actual))
+	for _, alias := range actual {
+		actualSet[strings.ToLower(alias)] = true
+	}
+	expectedSet := make(map[string]bool, len(expected))
+	for _, alias := range expected {
+		expectedSet[strings.ToLower(alias)] = true
+		if !actualSet[strings.ToLower(alias)] {
+			missing = append(missing, alias)
+		}
+	}
+	for _, alias := range actual {
+		if !expectedSet[strings.ToLower(alias)] {
+			unexpected = append(unexpected, alias)
+		}
+	}
+	return
+}
+
+// MemoryPendingStore is an in-memory PendingStore (pending payments are lost on restart)
+type MemoryPendingStore struct {
+	mu       sync.Mutex
+	payments map[string]*ExpectedPayment
+}
+
+// NewMemoryPendingStore will return a new in-memory store
+func NewMemoryPendingStore() *MemoryPendingStore {
+	return &MemoryPendingStore{payments: make(map[string]*ExpectedPayment)}
+}
+
+// Add will store the pending payment
+func (m *MemoryPendingStore) Add(_ context.Context, payment *ExpectedPayment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payments[payment.TransactionID] = payment
+	return nil
+}
+
+// List will return all the pending payments (sorted by transaction ID)
+func (m *MemoryPendingStore) List(_ context.Context) ([]*ExpectedPayment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	payments := make([]*ExpectedPayment, 0, len(m.payments))
+	for _, payment := range m.payments {
+		payments = append(payments, payment)
+	}
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].TransactionID < payments[j].TransactionID
+	})
+	return payments, nil
+}
+
+// Remove will delete the pending payment
+func (m *MemoryPendingStore) Remove(_ context.Context, transactionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.payments, transactionID)
+	return nil
+}
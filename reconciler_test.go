@@ -0,0 +1,277 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHTTPReconcile for mocking payments by transaction id (unknown payments are not found)
+type mockHTTPReconcile struct {
+	payments map[string]*PaymentResponse
+}
+
+// Do is a mock http request
+func (m *mockHTTPReconcile) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	// Find the payment
+	request := new(PaymentRequest)
+	body, _ := ioutil.ReadAll(req.Body)
+	if err := json.Unmarshal(body, request); err != nil {
+		return resp, err
+	}
+	payment, ok := m.payments[request.TransactionID]
+	if !ok {
+		resp.StatusCode = http.StatusNotFound
+		resp.Body = ioutil.NopCloser(bytes.NewBufferString(`{"message":"payment not found"}`))
+		return resp, nil
+	}
+
+	resp.StatusCode = http.StatusOK
+	body, _ = json.Marshal(payment)
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	return resp, nil
+}
+
+// newTestReconciler returns a reconciler using the mock payments
+func newTestReconciler(payments ...*PaymentResponse) (*Reconciler, *MemoryPendingStore) {
+	mock := &mockHTTPReconcile{payments: make(map[string]*PaymentResponse)}
+	for _, payment := range payments {
+		mock.payments[payment.TransactionID] = payment
+	}
+	store := NewMemoryPendingStore()
+	return NewReconciler(newTestClient(mock, EnvironmentBeta), "000000", store, &ReconcilerOptions{
+		EventBuffer: 10,
+		Interval:    10 * time.Millisecond,
+	}), store
+}
+
+// newTestReconcilePayment returns a payment to reconcile
+func newTestReconcilePayment(transactionID string) *PaymentResponse {
+	return &PaymentResponse{
+		Attachments:   []*Attachment{{Format: AttachmentFormatJSON, Value: map[string]interface{}{"some": "data"}}},
+		Participants:  []*Participant{{Alias: "MisterZ", Type: ParticipantUser}},
+		SatoshiAmount: 5000,
+		SatoshiFees:   100,
+		TransactionID: transactionID,
+	}
+}
+
+// flakyPendingStore fails to list the pending payments the first time
+type flakyPendingStore struct {
+	*MemoryPendingStore
+	failed bool
+}
+
+// List will fail the first time
+func (s *flakyPendingStore) List(ctx context.Context) ([]*ExpectedPayment, error) {
+	if !s.failed {
+		s.failed = true
+		return nil, fmt.Errorf("store is down")
+	}
+	return s.MemoryPendingStore.List(ctx)
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("matched payment", func(t *testing.T) {
+		reconciler, store := newTestReconciler(newTestReconcilePayment("tx-1"))
+		require.NoError(t, reconciler.Add(context.Background(), &ExpectedPayment{
+			Attachments:    []*Attachment{{Format: AttachmentFormatJSON, Value: map[string]string{"some": "data"}}},
+			MaxSatoshiFees: 200,
+			Receivers:      []string{"misterz"},
+			SatoshiAmount:  5000,
+			TransactionID:  "tx-1",
+		}))
+
+		require.NoError(t, reconciler.Reconcile(context.Background()))
+		event := <-reconciler.Events()
+		assert.Equal(t, ReconcileMatched, event.Type)
+		assert.Empty(t, event.Mismatches)
+		assert.Equal(t, "tx-1", event.Payment.TransactionID)
+
+		pending, err := store.List(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("mismatched payment", func(t *testing.T) {
+		reconciler, store := newTestReconciler(newTestReconcilePayment("tx-1"))
+		require.NoError(t, reconciler.Add(context.Background(), &ExpectedPayment{
+			Attachments:    []*Attachment{{Format: AttachmentFormatJSON, Value: map[string]string{"some": "other"}}},
+			MaxSatoshiFees: 50,
+			Receivers:      []string{"someone"},
+			SatoshiAmount:  4000,
+			TransactionID:  "tx-1",
+		}))
+
+		require.NoError(t, reconciler.Reconcile(context.Background()))
+		event := <-reconciler.Events()
+		assert.Equal(t, ReconcileMismatch, event.Type)
+		require.Equal(t, 4, len(event.Mismatches))
+		assert.Equal(t, "satoshiAmount: expected 4000, got 5000", event.Mismatches[0])
+		assert.Equal(t, "satoshiFees: expected at most 50, got 100", event.Mismatches[1])
+		assert.Equal(t, "participants: missing [someone], unexpected [MisterZ]", event.Mismatches[2])
+		assert.Contains(t, event.Mismatches[3], "attachments:")
+
+		pending, err := store.List(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("invalid raw transaction", func(t *testing.T) {
+		payment := newTestPaymentResponse()
+		reconciler, _ := newTestReconciler(payment)
+		require.NoError(t, reconciler.Add(context.Background(), &ExpectedPayment{
+			Outputs:       []*TransactionOutput{{LockingScript: testChangeLockingScript, Satoshis: 5372}},
+			SatoshiAmount: 5372,
+			TransactionID: payment.TransactionID,
+		}))
+
+		require.NoError(t, reconciler.Reconcile(context.Background()))
+		event := <-reconciler.Events()
+		assert.Equal(t, ReconcileMismatch, event.Type)
+		require.Equal(t, 1, len(event.Mismatches))
+		assert.Contains(t, event.Mismatches[0], "transaction:")
+	})
+
+	t.Run("failed lookup stays pending", func(t *testing.T) {
+		reconciler, store := newTestReconciler()
+		require.NoError(t, reconciler.Add(context.Background(), &ExpectedPayment{SatoshiAmount: 1, TransactionID: "tx-1"}))
+
+		require.NoError(t, reconciler.Reconcile(context.Background()))
+		event := <-reconciler.Events()
+		assert.Equal(t, ReconcileError, event.Type)
+		assert.Equal(t, "payment not found", event.Error.Error())
+
+		pending, err := store.List(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(pending))
+	})
+
+	t.Run("event is not lost if the context is done", func(t *testing.T) {
+		reconciler, store := newTestReconciler(newTestReconcilePayment("tx-1"))
+		reconciler.events = make(chan *ReconcileEvent) // Nobody is reading
+		require.NoError(t, reconciler.Add(context.Background(), &ExpectedPayment{SatoshiAmount: 5000, TransactionID: "tx-1"}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, reconciler.Reconcile(ctx), context.DeadlineExceeded)
+
+		pending, err := store.List(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(pending))
+	})
+
+	t.Run("missing transaction id", func(t *testing.T) {
+		reconciler, _ := newTestReconciler()
+		assert.Error(t, reconciler.Add(context.Background(), nil))
+		assert.Error(t, reconciler.Add(context.Background(), &ExpectedPayment{}))
+	})
+}
+
+func TestReconciler_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("resumes pending payments from the store", func(t *testing.T) {
+		reconciler, store := newTestReconciler(newTestReconcilePayment("tx-1"), newTestReconcilePayment("tx-2"))
+		require.NoError(t, store.Add(context.Background(), &ExpectedPayment{SatoshiAmount: 5000, TransactionID: "tx-1"}))
+		require.NoError(t, store.Add(context.Background(), &ExpectedPayment{SatoshiAmount: 5000, TransactionID: "tx-2"}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			done <- reconciler.Run(ctx)
+		}()
+
+		var matched []string
+		for event := range reconciler.Events() {
+			assert.Equal(t, ReconcileMatched, event.Type)
+			if matched = append(matched, event.Expected.TransactionID); len(matched) == 2 {
+				cancel()
+			}
+		}
+		assert.ErrorIs(t, <-done, context.Canceled)
+		assert.Equal(t, []string{"tx-1", "tx-2"}, matched)
+	})
+
+	t.Run("keeps polling after a failed pass", func(t *testing.T) {
+		reconciler, memoryStore := newTestReconciler(newTestReconcilePayment("tx-1"))
+		require.NoError(t, memoryStore.Add(context.Background(), &ExpectedPayment{SatoshiAmount: 5000, TransactionID: "tx-1"}))
+		reconciler.store = &flakyPendingStore{MemoryPendingStore: memoryStore}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			done <- reconciler.Run(ctx)
+		}()
+
+		event := <-reconciler.Events()
+		assert.Equal(t, ReconcileError, event.Type)
+		assert.Nil(t, event.Expected)
+		require.Error(t, event.Error)
+		assert.Contains(t, event.Error.Error(), "store is down")
+
+		event = <-reconciler.Events()
+		assert.Equal(t, ReconcileMatched, event.Type)
+		assert.Equal(t, "tx-1", event.Expected.TransactionID)
+
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	})
+
+	t.Run("closed after run returns", func(t *testing.T) {
+		reconciler, _ := newTestReconciler()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, reconciler.Run(ctx), context.Canceled)
+
+		// The events channel is closed, so nothing else can run
+		assert.ErrorIs(t, reconciler.Reconcile(context.Background()), ErrReconcilerClosed)
+		assert.ErrorIs(t, reconciler.Run(context.Background()), ErrReconcilerClosed)
+		_, ok := <-reconciler.Events()
+		assert.False(t, ok)
+	})
+
+	t.Run("already running", func(t *testing.T) {
+		reconciler, store := newTestReconciler(newTestReconcilePayment("tx-1"))
+		require.NoError(t, store.Add(context.Background(), &ExpectedPayment{SatoshiAmount: 5000, TransactionID: "tx-1"}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- reconciler.Run(ctx)
+		}()
+		<-reconciler.Events()
+
+		err := reconciler.Run(ctx)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrReconcilerClosed)
+
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	})
+
+	t.Run("default options", func(t *testing.T) {
+		reconciler := NewReconciler(newTestClient(&mockHTTPGetPayment{}, EnvironmentBeta), "000000", nil, nil)
+		assert.Equal(t, defaultReconcileInterval, reconciler.interval)
+		assert.NotNil(t, reconciler.store)
+	})
+}
@@ -0,0 +1,61 @@
+package handcash
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/url"
+)
+
+// GetRedirectionURL will return the HandCash authorization URL for the given app
+//
+// Optional query parameters (such as RedirectParamState and RedirectParamReferrer)
+// are passed along and returned to the app's callback URL
+//
+// Specs: https://github.com/HandCash/handcash-connect-sdk-js/blob/master/src/handcash_connect.js
+func (c *Client) GetRedirectionURL(appID string, params map[string]string) (string, error) {
+
+	// Make sure we have an app id and an environment
+	if len(appID) == 0 {
+		return "", fmt.Errorf("missing app id")
+	} else if c.environmentErr != nil {
+		return "", c.environmentErr
+	}
+
+	// Set the query parameters
+	values := url.Values{}
+	for key, value := range params {
+		if len(key) > 0 {
+			values.Set(key, value)
+		}
+	}
+	values.Set(redirectParamAppID, appID)
+
+	return c.Environment.ClientURL + redirectPathAuthorize + "?" + values.Encode(), nil
+}
+
+// ParseRedirectionCallback will return the auth token from the app's callback URL
+//
+// If expectedState is set, the state query parameter must match it
+func ParseRedirectionCallback(callbackURL, expectedState string) (string, error) {
+
+	// Parse the callback
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid callback url: %w", err)
+	}
+	query := u.Query()
+
+	// Check the state
+	if len(expectedState) > 0 && subtle.ConstantTimeCompare(
+		[]byte(query.Get(RedirectParamState)), []byte(expectedState),
+	) != 1 {
+		return "", fmt.Errorf("invalid state")
+	}
+
+	// Make sure we have an auth token
+	authToken := query.Get(RedirectParamAuthToken)
+	if len(authToken) == 0 {
+		return "", fmt.Errorf("missing auth token")
+	}
+	return authToken, nil
+}
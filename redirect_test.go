@@ -0,0 +1,101 @@
+package handcash
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetRedirectionURL(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing app id", func(t *testing.T) {
+		client := NewClient(nil, nil, EnvironmentBeta)
+		redirectURL, err := client.GetRedirectionURL("", nil)
+		assert.Error(t, err)
+		assert.Equal(t, "", redirectURL)
+	})
+
+	t.Run("valid url (beta)", func(t *testing.T) {
+		client := NewClient(nil, nil, EnvironmentBeta)
+		redirectURL, err := client.GetRedirectionURL("app-id-123", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://beta-app.handcash.io/#/authorizeApp?appId=app-id-123", redirectURL)
+	})
+
+	t.Run("valid url (production)", func(t *testing.T) {
+		client := NewClient(nil, nil, EnvironmentProduction)
+		redirectURL, err := client.GetRedirectionURL("app-id-123", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://app.handcash.io/#/authorizeApp?appId=app-id-123", redirectURL)
+	})
+
+	t.Run("state and referrer", func(t *testing.T) {
+		client := NewClient(nil, nil, EnvironmentBeta)
+		redirectURL, err := client.GetRedirectionURL("app-id-123", map[string]string{
+			RedirectParamReferrer: "MisterZ",
+			RedirectParamState:    "abc 123",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "https://beta-app.handcash.io/#/authorizeApp?appId=app-id-123&referrerHandle=MisterZ&state=abc+123", redirectURL)
+	})
+
+	t.Run("app id can't be overwritten", func(t *testing.T) {
+		client := NewClient(nil, nil, EnvironmentBeta)
+		redirectURL, err := client.GetRedirectionURL("app-id-123", map[string]string{
+			redirectParamAppID: "other",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "https://beta-app.handcash.io/#/authorizeApp?appId=app-id-123", redirectURL)
+	})
+}
+
+// ExampleClient_GetRedirectionURL example using GetRedirectionURL()
+func ExampleClient_GetRedirectionURL() {
+	client := NewClient(nil, nil, EnvironmentBeta)
+	redirectURL, _ := client.GetRedirectionURL("your-app-id", map[string]string{RedirectParamState: "xyz"})
+
+	fmt.Printf("redirect to: %s", redirectURL)
+	// Output:redirect to: https://beta-app.handcash.io/#/authorizeApp?appId=your-app-id&state=xyz
+}
+
+func TestParseRedirectionCallback(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid callback", func(t *testing.T) {
+		authToken, err := ParseRedirectionCallback("https://example.com/callback?authToken=000000", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "000000", authToken)
+	})
+
+	t.Run("valid callback with state", func(t *testing.T) {
+		authToken, err := ParseRedirectionCallback("https://example.com/callback?authToken=000000&state=xyz", "xyz")
+		assert.NoError(t, err)
+		assert.Equal(t, "000000", authToken)
+	})
+
+	t.Run("invalid state", func(t *testing.T) {
+		authToken, err := ParseRedirectionCallback("https://example.com/callback?authToken=000000&state=abc", "xyz")
+		assert.Error(t, err)
+		assert.Equal(t, "", authToken)
+	})
+
+	t.Run("missing state", func(t *testing.T) {
+		authToken, err := ParseRedirectionCallback("https://example.com/callback?authToken=000000", "xyz")
+		assert.Error(t, err)
+		assert.Equal(t, "", authToken)
+	})
+
+	t.Run("missing auth token", func(t *testing.T) {
+		authToken, err := ParseRedirectionCallback("https://example.com/callback?state=xyz", "xyz")
+		assert.Error(t, err)
+		assert.Equal(t, "", authToken)
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		authToken, err := ParseRedirectionCallback("://bad-url", "")
+		assert.Error(t, err)
+		assert.Equal(t, "", authToken)
+	})
+}
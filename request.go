@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RequestResponse is the response from a request
 type RequestResponse struct {
+	Attempts     int    `json:"attempts"`      // Attempts is the number of times the request was sent (including retries)
 	BodyContents []byte `json:"body_contents"` // Raw body response
 	Error        error  `json:"error"`         // If an error occurs
 	Method       string `json:"method"`        // Method is the HTTP method used
@@ -25,6 +29,7 @@ type httpPayload struct {
 	Data           []byte `json:"data"`
 	ExpectedStatus int    `json:"expected_status"`
 	Method         string `json:"method"`
+	NoRetry        bool   `json:"no_retry"` // Never retried (IE: payments)
 	URL            string `json:"url"`
 }
 
@@ -32,53 +37,56 @@ type httpPayload struct {
 func httpRequest(ctx context.Context, client *Client,
 	payload *httpPayload, signedRequest *signedRequest) (response *RequestResponse) {
 
-	// Set reader
-	var bodyReader io.Reader
-
 	// Start the response
 	response = new(RequestResponse)
 
-	// Add post data if applicable
+	// Store for debugging purposes
 	if payload.Method == http.MethodPost || payload.Method == http.MethodPut {
-		bodyReader = bytes.NewBuffer(payload.Data)
 		response.PostData = string(payload.Data)
-	} else if payload.Method == http.MethodGet {
-		// HandCash requires data even on a GET request (DO NOT REMOVE)
-		if len(payload.Data) > 0 {
-			bodyReader = bytes.NewBuffer(payload.Data) // empty: {}
-		}
 	}
-
-	// Store for debugging purposes
 	response.Method = payload.Method
 	response.URL = payload.URL
 
-	// Start the request
+	// Trace, log and collect metrics for the request once finished
 	var request *http.Request
-	if request, response.Error = http.NewRequestWithContext(
-		ctx, payload.Method, payload.URL, bodyReader,
-	); response.Error != nil {
-		return
-	}
-
-	// Change the header (user agent is in case they block default Go user agents)
-	request.Header.Set("User-Agent", client.Options.UserAgent)
-
-	// Set the content type on Method
-	if payload.Method == http.MethodPost || payload.Method == http.MethodPut {
-		request.Header.Set("Content-Type", "application/json")
-	}
-
-	// Set oAuth headers
-	request.Header.Set("oauth-publickey", signedRequest.Headers.OauthPublicKey)
-	request.Header.Set("oauth-signature", signedRequest.Headers.OauthSignature)
-	request.Header.Set("oauth-timestamp", signedRequest.Headers.OauthTimestamp)
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start)
+		retries := response.Attempts - 1
+		if retries < 0 {
+			retries = 0
+		}
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.String(attributeEndpoint, signedRequest.Endpoint),
+			attribute.Int(attributeStatusCode, response.StatusCode),
+			attribute.Int(attributeRetryCount, retries),
+		)
+		if client.Options.MetricsCollector != nil {
+			client.Options.MetricsCollector.ObserveRequest(
+				signedRequest.Endpoint, payload.Method, response.StatusCode,
+				duration, retries, response.Error,
+			)
+		}
+		if client.Options.Logger != nil {
+			client.logRequest(ctx, request, response, signedRequest.Endpoint, duration, retries)
+		}
+	}()
 
-	// Fire the http request
+	// Fire the http request (retrying if allowed by the retry policy)
 	var resp *http.Response
-	if resp, response.Error = client.httpClient.Do(request); response.Error != nil {
+	if request, resp, response.Error = client.doWithRetries(ctx, payload, signedRequest.Headers.OauthPublicKey, func() (*http.Request, error) {
+
+		// Sign the retries again (a repeated timestamp and signature is a replayed request)
+		if response.Attempts > 1 && signedRequest.resign != nil {
+			if err := signedRequest.resign(currentISOTimestamp()); err != nil {
+				return nil, err
+			}
+		}
+		return newHTTPRequest(ctx, client, payload, signedRequest)
+	}, &response.Attempts); response.Error != nil {
 		if resp != nil {
 			response.StatusCode = resp.StatusCode
+			_ = resp.Body.Close()
 		}
 		return
 	}
@@ -98,23 +106,69 @@ func httpRequest(ctx context.Context, client *Client,
 
 	// Status does not match as expected
 	if resp.StatusCode != payload.ExpectedStatus {
+		apiErr := &APIError{
+			Body:       response.BodyContents,
+			Endpoint:   signedRequest.Endpoint,
+			Method:     payload.Method,
+			StatusCode: resp.StatusCode,
+		}
 
-		// Set the error message
+		// Set the error message (if found)
 		if len(response.BodyContents) > 0 {
 			errorMsg := new(errorResponse)
-			if response.Error = json.Unmarshal(
-				response.BodyContents, &errorMsg,
-			); response.Error != nil {
-				return
+			if err := json.Unmarshal(response.BodyContents, &errorMsg); err == nil {
+				apiErr.Message = errorMsg.Message
 			}
-			response.Error = fmt.Errorf("%s", errorMsg.Message)
-			return
 		}
-
-		// No error message found, set default error message
-		response.Error = fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+		response.Error = apiErr
 		return
 	}
 
 	return
 }
+
+// newHTTPRequest will create the request with the HandCash headers
+// (a new request is needed for every attempt)
+func newHTTPRequest(ctx context.Context, client *Client, payload *httpPayload,
+	signedRequest *signedRequest) (*http.Request, error) {
+
+	// Set reader
+	var bodyReader io.Reader
+
+	// Add post data if applicable
+	if payload.Method == http.MethodPost || payload.Method == http.MethodPut {
+		bodyReader = bytes.NewReader(payload.Data)
+	} else if payload.Method == http.MethodGet {
+		// HandCash requires data even on a GET request (DO NOT REMOVE)
+		if len(payload.Data) > 0 {
+			bodyReader = bytes.NewReader(payload.Data) // empty: {}
+		}
+	}
+
+	// Add the request info for middleware (every attempt, as retries are signed again)
+	ctx = context.WithValue(ctx, requestInfoKey{}, newRequestInfo(signedRequest))
+
+	// Start the request
+	request, err := http.NewRequestWithContext(ctx, payload.Method, payload.URL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Change the header (user agent is in case they block default Go user agents)
+	request.Header.Set("User-Agent", client.Options.UserAgent)
+
+	// Set the content type on Method
+	if payload.Method == http.MethodPost || payload.Method == http.MethodPut {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	// Set oAuth headers
+	request.Header.Set("oauth-publickey", signedRequest.Headers.OauthPublicKey)
+	request.Header.Set("oauth-signature", signedRequest.Headers.OauthSignature)
+	request.Header.Set("oauth-timestamp", signedRequest.Headers.OauthTimestamp)
+
+	// Set the app headers (if the client was created for an app)
+	client.setAppHeaders(request)
+
+	return request, nil
+}
@@ -0,0 +1,172 @@
+package handcash
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides which requests are retried and how long to wait between attempts
+//
+// By default, only requests that are safe to repeat are retried (IE: GET) when
+// there is a network error, a 429 or a 5xx response. POST requests are never retried,
+// unless RetryPOST is enabled (payments are never retried). Every attempt is signed
+// again with a new timestamp.
+type RetryPolicy struct {
+	BackoffFactor  float64       `json:"backoff_factor"`  // Multiplier for the wait after every attempt
+	InitialBackoff time.Duration `json:"initial_backoff"` // Wait before the first retry
+	MaxBackoff     time.Duration `json:"max_backoff"`     // Maximum wait between attempts (before jitter)
+	MaxJitter      time.Duration `json:"max_jitter"`      // Maximum random time added to every wait
+	MaxRetries     int           `json:"max_retries"`     // Maximum retries per request (0 disables retries)
+	MaxRetryAfter  time.Duration `json:"max_retry_after"` // Longest Retry-After to honor (longer is not retried)
+	RetryPOST      bool          `json:"retry_post"`      // Retry POST requests (only if duplicates are safe)
+	RetryStatuses  []int         `json:"retry_statuses"`  // Status codes to retry (defaults to 429 and 5xx)
+}
+
+// DefaultRetryPolicy will return a RetryPolicy with the default settings
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		BackoffFactor:  2.0,
+		InitialBackoff: 2 * time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+		MaxJitter:      2 * time.Millisecond,
+		MaxRetries:     2,
+		MaxRetryAfter:  10 * time.Second,
+	}
+}
+
+// retryPolicyFromOptions will return the retry policy using the client back-off options
+// (used if ClientOptions.RetryPolicy is not set)
+func retryPolicyFromOptions(options *ClientOptions) *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BackoffFactor = options.BackOffExponentFactor
+	policy.InitialBackoff = options.BackOffInitialTimeout
+	policy.MaxBackoff = options.BackOffMaxTimeout
+	policy.MaxJitter = options.BackOffMaximumJitterInterval
+	policy.MaxRetries = options.RequestRetryCount
+	return policy
+}
+
+// shouldRetry will return true if the attempt can be retried
+func (p *RetryPolicy) shouldRetry(method string, attempt int, resp *http.Response, err error) bool {
+
+	if p == nil || attempt > p.MaxRetries {
+		return false
+	} else if method == http.MethodPost && !p.RetryPOST {
+		return false
+	} else if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	} else if err != nil {
+		return true
+	} else if resp == nil {
+		return false
+	}
+
+	if len(p.RetryStatuses) > 0 {
+		for _, status := range p.RetryStatuses {
+			if resp.StatusCode == status {
+				return true
+			}
+		}
+		return false
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// delay will return the wait before the next attempt (Retry-After is honored if set)
+//
+// False is returned if the server asks to wait longer than MaxRetryAfter
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait, wait <= p.MaxRetryAfter
+		}
+	}
+
+	wait := float64(p.InitialBackoff) * math.Pow(math.Max(p.BackoffFactor, 1), float64(attempt-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+	if p.MaxJitter > 0 {
+		wait += float64(rand.Int63n(int64(p.MaxJitter)))
+	}
+	return time.Duration(wait), true
+}
+
+// parseRetryAfter will parse the Retry-After header (seconds or an HTTP date)
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// doWithRetries will fire the request, retrying as allowed by the retry policy
+//
+// A new request is created for every attempt, and the attempts are counted as they are made
+// (after checking the circuit breaker and waiting for the rate limiter using the public key
+// of the auth token)
+func (c *Client) doWithRetries(ctx context.Context, payload *httpPayload, publicKey string,
+	newRequest func() (*http.Request, error), attempts *int) (request *http.Request, resp *http.Response, err error) {
+
+	for {
+		// Fail fast if the circuit breaker is open (before waiting for the rate limiter)
+		if err = c.circuitBreaker.allow(); err != nil {
+			return
+		}
+
+		// Wait for the rate limiter (every attempt counts)
+		if err = c.rateLimiter.wait(ctx, publicKey); err != nil {
+			c.circuitBreaker.release()
+			return
+		}
+
+		*attempts++
+		if request, err = newRequest(); err != nil {
+			c.circuitBreaker.release()
+			return
+		}
+		resp, err = c.do(request)
+		c.circuitBreaker.record(ctx, resp, err)
+
+		// Finished?
+		if payload.NoRetry || !c.retryPolicy.shouldRetry(payload.Method, *attempts, resp, err) {
+			return
+		}
+		wait, ok := c.retryPolicy.delay(*attempts, resp)
+		if !ok {
+			return
+		}
+
+		// Discard the response and wait
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return request, nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package handcash
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRetryServer returns a server that fails with the status (and Retry-After)
+// until the given number of failures is reached
+func newTestRetryServer(failures int32, status int, retryAfter string, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(requests, 1) <= failures {
+			if len(retryAfter) > 0 {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+}
+
+// newTestRetryRequest fires a signed request against the server using the policy
+func newTestRetryRequest(t *testing.T, ctx context.Context, serverURL string, policy *RetryPolicy,
+	method string) *RequestResponse {
+
+	options := DefaultClientOptions()
+	options.RetryPolicy = policy
+	client := NewClient(options, nil, EnvironmentBeta)
+	client.Environment = &Environment{APIURL: serverURL, Environment: "test"}
+
+	signed, err := client.getSignedRequest(method, endpointProfileCurrent, testUserAuthToken, &requestBody{}, currentISOTimestamp())
+	require.NoError(t, err)
+
+	return httpRequest(ctx, client, &httpPayload{
+		Data:           []byte(emptyBody),
+		ExpectedStatus: http.StatusOK,
+		Method:         method,
+		URL:            signed.URI,
+	}, signed)
+}
+
+func TestRetryPolicy_shouldRetry(t *testing.T) {
+	t.Parallel()
+
+	policy := DefaultRetryPolicy()
+	status := func(code int) *http.Response { return &http.Response{StatusCode: code} }
+
+	tests := []struct {
+		name     string
+		method   string
+		attempt  int
+		resp     *http.Response
+		err      error
+		expected bool
+	}{
+		{"get 500", http.MethodGet, 1, status(http.StatusInternalServerError), nil, true},
+		{"get 503", http.MethodGet, 2, status(http.StatusServiceUnavailable), nil, true},
+		{"get 429", http.MethodGet, 1, status(http.StatusTooManyRequests), nil, true},
+		{"get network error", http.MethodGet, 1, nil, fmt.Errorf("connection reset"), true},
+		{"get 400", http.MethodGet, 1, status(http.StatusBadRequest), nil, false},
+		{"get 200", http.MethodGet, 1, status(http.StatusOK), nil, false},
+		{"get canceled", http.MethodGet, 1, nil, context.Canceled, false},
+		{"get max retries", http.MethodGet, 3, status(http.StatusInternalServerError), nil, false},
+		{"post 500", http.MethodPost, 1, status(http.StatusInternalServerError), nil, false},
+		{"post network error", http.MethodPost, 1, nil, fmt.Errorf("connection reset"), false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected,
+			policy.shouldRetry(test.method, test.attempt, test.resp, test.err),
+			test.name,
+		)
+	}
+
+	t.Run("retry post", func(t *testing.T) {
+		p := DefaultRetryPolicy()
+		p.RetryPOST = true
+		assert.True(t, p.shouldRetry(http.MethodPost, 1, status(http.StatusBadGateway), nil))
+	})
+
+	t.Run("custom statuses", func(t *testing.T) {
+		p := DefaultRetryPolicy()
+		p.RetryStatuses = []int{http.StatusConflict}
+		assert.True(t, p.shouldRetry(http.MethodGet, 1, status(http.StatusConflict), nil))
+		assert.False(t, p.shouldRetry(http.MethodGet, 1, status(http.StatusInternalServerError), nil))
+	})
+
+	t.Run("nil policy", func(t *testing.T) {
+		var p *RetryPolicy
+		assert.False(t, p.shouldRetry(http.MethodGet, 1, status(http.StatusInternalServerError), nil))
+	})
+}
+
+func TestRetryPolicy_delay(t *testing.T) {
+	t.Parallel()
+
+	t.Run("exponential backoff", func(t *testing.T) {
+		p := &RetryPolicy{BackoffFactor: 2, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}
+		for attempt, expected := range map[int]time.Duration{
+			1: 10 * time.Millisecond,
+			2: 20 * time.Millisecond,
+			3: 30 * time.Millisecond,
+			4: 30 * time.Millisecond,
+		} {
+			wait, ok := p.delay(attempt, nil)
+			assert.True(t, ok)
+			assert.Equal(t, expected, wait)
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		p := &RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxJitter: 5 * time.Millisecond}
+		wait, ok := p.delay(1, nil)
+		assert.True(t, ok)
+		assert.GreaterOrEqual(t, wait, 10*time.Millisecond)
+		assert.Less(t, wait, 15*time.Millisecond)
+	})
+
+	t.Run("retry after", func(t *testing.T) {
+		p := DefaultRetryPolicy()
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+		wait, ok := p.delay(1, resp)
+		assert.True(t, ok)
+		assert.Equal(t, 3*time.Second, wait)
+
+		resp.Header.Set("Retry-After", "60")
+		_, ok = p.delay(1, resp)
+		assert.False(t, ok)
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	wait, ok := parseRetryAfter("120")
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, wait)
+
+	wait, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.InDelta(t, float64(time.Hour), float64(wait), float64(2*time.Second))
+
+	wait, ok = parseRetryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), wait)
+
+	for _, value := range []string{"", "-1", "soon"} {
+		_, ok = parseRetryAfter(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestHTTPRequest_Retries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get is retried", func(t *testing.T) {
+		var requests int32
+		server := newTestRetryServer(2, http.StatusBadGateway, "", &requests)
+		defer server.Close()
+
+		response := newTestRetryRequest(t, context.Background(), server.URL, DefaultRetryPolicy(), http.MethodGet)
+		require.NoError(t, response.Error)
+		assert.Equal(t, 3, response.Attempts)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+
+	t.Run("retry after is honored", func(t *testing.T) {
+		var requests int32
+		server := newTestRetryServer(1, http.StatusTooManyRequests, "0", &requests)
+		defer server.Close()
+
+		response := newTestRetryRequest(t, context.Background(), server.URL, DefaultRetryPolicy(), http.MethodGet)
+		require.NoError(t, response.Error)
+		assert.Equal(t, 2, response.Attempts)
+	})
+
+	t.Run("retry after is too long", func(t *testing.T) {
+		var requests int32
+		server := newTestRetryServer(1, http.StatusTooManyRequests, "3600", &requests)
+		defer server.Close()
+
+		response := newTestRetryRequest(t, context.Background(), server.URL, DefaultRetryPolicy(), http.MethodGet)
+		require.Error(t, response.Error)
+		assert.True(t, IsRateLimited(response.Error))
+		assert.Equal(t, 1, response.Attempts)
+	})
+
+	t.Run("post is not retried", func(t *testing.T) {
+		var requests int32
+		server := newTestRetryServer(1, http.StatusInternalServerError, "", &requests)
+		defer server.Close()
+
+		response := newTestRetryRequest(t, context.Background(), server.URL, DefaultRetryPolicy(), http.MethodPost)
+		require.Error(t, response.Error)
+		assert.Equal(t, 1, response.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	})
+
+	t.Run("payments are never retried", func(t *testing.T) {
+		var requests int32
+		server := newTestRetryServer(1, http.StatusBadGateway, "", &requests)
+		defer server.Close()
+
+		options := DefaultClientOptions()
+		options.RetryPolicy = DefaultRetryPolicy()
+		options.RetryPolicy.RetryPOST = true
+		client, err := NewClientWithEnvironment(options, nil, &Environment{APIURL: server.URL, ClientURL: server.URL})
+		require.NoError(t, err)
+
+		_, err = client.Pay(context.Background(), "000000", newTestPayParameters("key"))
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("retries are signed again", func(t *testing.T) {
+		var requests int32
+		var timestamps []string
+		cache := NewMemoryReplayCache(10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			timestamps = append(timestamps, req.Header.Get("oauth-timestamp"))
+			if err := VerifySignedRequestWithCache(req, time.Minute, cache); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if atomic.AddInt32(&requests, 1) <= 2 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		policy := DefaultRetryPolicy()
+		policy.InitialBackoff = 5 * time.Millisecond
+		response := newTestRetryRequest(t, context.Background(), server.URL, policy, http.MethodGet)
+		require.NoError(t, response.Error)
+		assert.Equal(t, 3, response.Attempts)
+		require.Equal(t, 3, len(timestamps))
+		assert.NotEqual(t, timestamps[0], timestamps[1])
+		assert.NotEqual(t, timestamps[1], timestamps[2])
+	})
+
+	t.Run("retries disabled", func(t *testing.T) {
+		var requests int32
+		server := newTestRetryServer(1, http.StatusInternalServerError, "", &requests)
+		defer server.Close()
+
+		policy := DefaultRetryPolicy()
+		policy.MaxRetries = 0
+		response := newTestRetryRequest(t, context.Background(), server.URL, policy, http.MethodGet)
+		require.Error(t, response.Error)
+		assert.Equal(t, 1, response.Attempts)
+	})
+
+	t.Run("context canceled while waiting", func(t *testing.T) {
+		var requests int32
+		server := newTestRetryServer(1, http.StatusServiceUnavailable, "5", &requests)
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		response := newTestRetryRequest(t, ctx, server.URL, DefaultRetryPolicy(), http.MethodGet)
+		require.ErrorIs(t, response.Error, context.DeadlineExceeded)
+		assert.Equal(t, 1, response.Attempts)
+	})
+}
@@ -0,0 +1,155 @@
+package handcash
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/libsv/go-bk/bec"
+)
+
+/*
+{
+  "publicKey": "0275e7081e5b6e73c94998098e075c0ed888d1eb33c721ee38ee741648b108c90d",
+  "signature": "304402207e3b0c45..."
+}
+*/
+
+// SignData will sign the given value using the user's private key
+//
+// Specs: https://github.com/HandCash/handcash-connect-sdk-js/blob/master/src/profile/index.js
+func (c *Client) SignData(ctx context.Context, authToken, value string,
+	format DataFormat) (_ *SignDataResponse, err error) {
+
+	// Start the span
+	ctx, span := c.startSpan(ctx, "SignData")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// Make sure we have an auth token
+	if len(authToken) == 0 {
+		return nil, fmt.Errorf("missing auth token")
+	} else if len(value) == 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+
+	// Default to utf-8 if not set
+	if len(format) == 0 {
+		format = DataFormatUTF8
+	}
+
+	// Get the signed request
+	signed, err := c.getSignedRequest(
+		http.MethodPost,
+		endpointSignData,
+		authToken,
+		&SignDataRequest{Format: format, Value: value},
+		currentISOTimestamp(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating signed request: %w", err)
+	}
+
+	// Convert into bytes
+	var params []byte
+	if params, err = json.Marshal(
+		&SignDataRequest{Format: format, Value: value},
+	); err != nil {
+		return nil, err
+	}
+
+	// Make the HTTP request
+	response := httpRequest(
+		ctx,
+		c,
+		&httpPayload{
+			Data:           params,
+			ExpectedStatus: http.StatusOK,
+			Method:         signed.Method,
+			URL:            signed.URI,
+		},
+		signed,
+	)
+
+	// Error in request?
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	// Unmarshal sign data response
+	signDataResponse := new(SignDataResponse)
+	if err = json.Unmarshal(response.BodyContents, &signDataResponse); err != nil {
+		return nil, fmt.Errorf("failed unmarshal: %w", err)
+	} else if signDataResponse == nil || signDataResponse.Signature == "" {
+		return nil, fmt.Errorf("failed to sign data")
+	}
+	return signDataResponse, nil
+}
+
+// VerifySignedData will verify that the signature (DER, hex or base64 encoded) of the
+// value was created by the private key belonging to the given public key (hex)
+//
+// The value and format are the same as passed to SignData() (utf-8 if the format is not set)
+func VerifySignedData(publicKey, value string, format DataFormat, signature string) (bool, error) {
+
+	// Decode the value
+	data, err := decodeDataValue(value, format)
+	if err != nil {
+		return false, err
+	}
+
+	// Decode the public key
+	var pubKeyBytes []byte
+	if pubKeyBytes, err = hex.DecodeString(publicKey); err != nil {
+		return false, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	var pubKey *bec.PublicKey
+	if pubKey, err = bec.ParsePubKey(pubKeyBytes, bec.S256()); err != nil {
+		return false, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	// Decode the signature (hex or base64)
+	var sigBytes []byte
+	if sigBytes, err = hex.DecodeString(signature); err != nil {
+		if sigBytes, err = base64.StdEncoding.DecodeString(signature); err != nil {
+			return false, fmt.Errorf("invalid signature encoding: %w", err)
+		}
+	}
+
+	var sig *bec.Signature
+	if sig, err = bec.ParseDERSignature(sigBytes, bec.S256()); err != nil {
+		return false, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	// Verify the signature against the hash of the value
+	hash := sha256.Sum256(data)
+	return sig.Verify(hash[:], pubKey), nil
+}
+
+// decodeDataValue will return the bytes of the value that are signed for the format
+func decodeDataValue(value string, format DataFormat) ([]byte, error) {
+	switch format {
+	case "", DataFormatUTF8:
+		return []byte(value), nil
+	case DataFormatHex:
+		data, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex value: %w", err)
+		}
+		return data, nil
+	case DataFormatBase64:
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 value: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unknown data format: %s", format)
+	}
+}
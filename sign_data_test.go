@@ -0,0 +1,227 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockHTTPSignData for mocking requests
+type mockHTTPSignData struct {
+	publicKey string
+	signature string
+}
+
+// Do is a mock http request
+func (m *mockHTTPSignData) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	// Beta
+	if req.URL.String() == environments[EnvironmentBeta].APIURL+endpointSignData {
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(
+			`{"publicKey":"` + m.publicKey + `","signature":"` + m.signature + `"}`,
+		)))
+	}
+
+	// Default is valid
+	return resp, nil
+}
+
+// mockHTTPInvalidSignData for mocking requests
+type mockHTTPInvalidSignData struct{}
+
+// Do is a mock http request
+func (m *mockHTTPInvalidSignData) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	resp.StatusCode = http.StatusOK
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"invalid":"signature"}`)))
+
+	// Default is valid
+	return resp, nil
+}
+
+// newTestSignature will sign the value with a new private key (public key, DER signature)
+func newTestSignature(t *testing.T, value string) (string, []byte) {
+	privateKey, err := bitcoin.CreatePrivateKey()
+	assert.NoError(t, err)
+
+	hash := sha256.Sum256([]byte(value))
+	sig, err := privateKey.Sign(hash[:])
+	assert.NoError(t, err)
+
+	return hex.EncodeToString(privateKey.PubKey().SerialiseCompressed()), sig.Serialise()
+}
+
+func TestClient_SignData(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPSignData{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		signature, err := client.SignData(context.Background(), "", "hello world", DataFormatUTF8)
+		assert.Error(t, err)
+		assert.Nil(t, signature)
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		client := newTestClient(&mockHTTPSignData{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		signature, err := client.SignData(context.Background(), "000000", "", DataFormatUTF8)
+		assert.Error(t, err)
+		assert.Nil(t, signature)
+	})
+
+	t.Run("invalid auth token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPSignData{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		signature, err := client.SignData(context.Background(), "0", "hello world", DataFormatUTF8)
+		assert.Error(t, err)
+		assert.Nil(t, signature)
+	})
+
+	t.Run("valid signature", func(t *testing.T) {
+		publicKey, sig := newTestSignature(t, "hello world")
+		client := newTestClient(&mockHTTPSignData{
+			publicKey: publicKey,
+			signature: hex.EncodeToString(sig),
+		}, EnvironmentBeta)
+		assert.NotNil(t, client)
+
+		signature, err := client.SignData(context.Background(), "000000", "hello world", "")
+		assert.NoError(t, err)
+		assert.NotNil(t, signature)
+		assert.Equal(t, publicKey, signature.PublicKey)
+
+		var verified bool
+		verified, err = VerifySignedData(signature.PublicKey, "hello world", DataFormatUTF8, signature.Signature)
+		assert.NoError(t, err)
+		assert.True(t, verified)
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		client := newTestClient(&mockHTTPBadRequest{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		signature, err := client.SignData(context.Background(), "000000", "hello world", DataFormatUTF8)
+		assert.Error(t, err)
+		assert.Nil(t, signature)
+	})
+
+	t.Run("invalid signature data", func(t *testing.T) {
+		client := newTestClient(&mockHTTPInvalidSignData{}, EnvironmentBeta)
+		assert.NotNil(t, client)
+		signature, err := client.SignData(context.Background(), "000000", "hello world", DataFormatUTF8)
+		assert.Error(t, err)
+		assert.Nil(t, signature)
+	})
+}
+
+func TestVerifySignedData(t *testing.T) {
+	t.Parallel()
+
+	publicKey, sig := newTestSignature(t, "hello world")
+
+	t.Run("valid hex signature", func(t *testing.T) {
+		verified, err := VerifySignedData(publicKey, "hello world", DataFormatUTF8, hex.EncodeToString(sig))
+		assert.NoError(t, err)
+		assert.True(t, verified)
+	})
+
+	t.Run("valid base64 signature", func(t *testing.T) {
+		verified, err := VerifySignedData(publicKey, "hello world", DataFormatUTF8, base64.StdEncoding.EncodeToString(sig))
+		assert.NoError(t, err)
+		assert.True(t, verified)
+	})
+
+	t.Run("default format", func(t *testing.T) {
+		verified, err := VerifySignedData(publicKey, "hello world", "", hex.EncodeToString(sig))
+		assert.NoError(t, err)
+		assert.True(t, verified)
+	})
+
+	t.Run("hex and base64 values", func(t *testing.T) {
+		data := string([]byte{0x00, 0xff, 0x10, 0x80})
+		dataKey, dataSig := newTestSignature(t, data)
+
+		verified, err := VerifySignedData(dataKey, "00ff1080", DataFormatHex, hex.EncodeToString(dataSig))
+		assert.NoError(t, err)
+		assert.True(t, verified)
+
+		verified, err = VerifySignedData(dataKey, base64.StdEncoding.EncodeToString([]byte(data)),
+			DataFormatBase64, hex.EncodeToString(dataSig))
+		assert.NoError(t, err)
+		assert.True(t, verified)
+
+		// The encoded string is not what was signed
+		verified, err = VerifySignedData(dataKey, "00ff1080", DataFormatUTF8, hex.EncodeToString(dataSig))
+		assert.NoError(t, err)
+		assert.False(t, verified)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		verified, err := VerifySignedData(publicKey, "not hex", DataFormatHex, hex.EncodeToString(sig))
+		assert.Error(t, err)
+		assert.False(t, verified)
+
+		verified, err = VerifySignedData(publicKey, "!not-base64!", DataFormatBase64, hex.EncodeToString(sig))
+		assert.Error(t, err)
+		assert.False(t, verified)
+
+		verified, err = VerifySignedData(publicKey, "hello world", "utf-16", hex.EncodeToString(sig))
+		assert.Error(t, err)
+		assert.False(t, verified)
+	})
+
+	t.Run("different value", func(t *testing.T) {
+		verified, err := VerifySignedData(publicKey, "goodbye world", DataFormatUTF8, hex.EncodeToString(sig))
+		assert.NoError(t, err)
+		assert.False(t, verified)
+	})
+
+	t.Run("different public key", func(t *testing.T) {
+		otherKey, _ := newTestSignature(t, "hello world")
+		verified, err := VerifySignedData(otherKey, "hello world", DataFormatUTF8, hex.EncodeToString(sig))
+		assert.NoError(t, err)
+		assert.False(t, verified)
+	})
+
+	t.Run("invalid public key", func(t *testing.T) {
+		verified, err := VerifySignedData("0", "hello world", DataFormatUTF8, hex.EncodeToString(sig))
+		assert.Error(t, err)
+		assert.False(t, verified)
+
+		verified, err = VerifySignedData("00", "hello world", DataFormatUTF8, hex.EncodeToString(sig))
+		assert.Error(t, err)
+		assert.False(t, verified)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		verified, err := VerifySignedData(publicKey, "hello world", DataFormatUTF8, "!not-encoded!")
+		assert.Error(t, err)
+		assert.False(t, verified)
+
+		verified, err = VerifySignedData(publicKey, "hello world", DataFormatUTF8, "0000")
+		assert.Error(t, err)
+		assert.False(t, verified)
+	})
+}
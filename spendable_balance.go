@@ -16,7 +16,13 @@ type SpendableBalanceResponse struct {
 
 // GetSpendableBalance gets the user's spendable balance from the handcash connect API
 func (c *Client) GetSpendableBalance(ctx context.Context, authToken string,
-	currencyCode CurrencyCode) (*SpendableBalanceResponse, error) {
+	currencyCode CurrencyCode) (_ *SpendableBalanceResponse, err error) {
+
+	// Start the span
+	ctx, span := c.startSpan(ctx, "GetSpendableBalance")
+	defer func() {
+		endSpan(span, err)
+	}()
 
 	// Make sure we have an auth token
 	if len(authToken) == 0 {
@@ -0,0 +1,243 @@
+package handcash
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrTokenNotFound is returned when the store has no auth token for the user
+var ErrTokenNotFound = errors.New("auth token not found")
+
+// tokenKeySize is the size of the file store encryption key (AES-256)
+const tokenKeySize = 32
+
+// TokenStore stores the auth tokens of the users (so business code only uses user IDs)
+type TokenStore interface {
+
+	// Delete removes the auth token of the user (no error if there is none)
+	Delete(ctx context.Context, userID string) error
+
+	// Get returns the auth token of the user (ErrTokenNotFound if there is none)
+	Get(ctx context.Context, userID string) (string, error)
+
+	// Set stores the auth token of the user (replacing any previous token)
+	Set(ctx context.Context, userID, authToken string) error
+}
+
+// validateToken will check the user ID and the auth token (a hex private key)
+func validateToken(userID, authToken string) error {
+	if len(userID) == 0 {
+		return fmt.Errorf("missing user id")
+	}
+	if tokenBytes, err := hex.DecodeString(authToken); err != nil || len(tokenBytes) != 32 {
+		return fmt.Errorf("invalid auth token for user: %s", userID)
+	}
+	return nil
+}
+
+// MemoryTokenStore is an in-memory TokenStore (tokens are lost on restart)
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]string
+}
+
+// NewMemoryTokenStore will return a new in-memory store
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]string)}
+}
+
+// Delete will remove the auth token of the user
+func (m *MemoryTokenStore) Delete(_ context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tokens, userID)
+	return nil
+}
+
+// Get will return the auth token of the user
+func (m *MemoryTokenStore) Get(_ context.Context, userID string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	authToken, ok := m.tokens[userID]
+	if !ok {
+		return "", ErrTokenNotFound
+	}
+	return authToken, nil
+}
+
+// Set will store the auth token of the user
+func (m *MemoryTokenStore) Set(_ context.Context, userID, authToken string) error {
+	if err := validateToken(userID, authToken); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens[userID] = authToken
+	return nil
+}
+
+// FileTokenStore is a TokenStore saved to a JSON file with every token encrypted (AES-256-GCM)
+//
+// Tokens are only decrypted when read, and each token is bound to its user ID (a token
+// copied to another user in the file will not decrypt). The file is written atomically
+// with owner-only permissions. The store is safe for concurrent use within a process.
+type FileTokenStore struct {
+	aead cipher.AEAD
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileTokenStore will return a new file store using the 32 byte key (the file is created on the first Set)
+func NewFileTokenStore(path string, key []byte) (*FileTokenStore, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("missing token file path")
+	} else if len(key) != tokenKeySize {
+		return nil, fmt.Errorf("invalid token key: must be %d bytes", tokenKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var aead cipher.AEAD
+	if aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	return &FileTokenStore{aead: aead, path: path}, nil
+}
+
+// Delete will remove the auth token of the user
+func (f *FileTokenStore) Delete(_ context.Context, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tokens, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[userID]; !ok {
+		return nil
+	}
+	delete(tokens, userID)
+	return f.save(tokens)
+}
+
+// Get will return the (decrypted) auth token of the user
+func (f *FileTokenStore) Get(_ context.Context, userID string) (string, error) {
+	f.mu.Lock()
+	tokens, err := f.load()
+	f.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, ok := tokens[userID]
+	if !ok {
+		return "", ErrTokenNotFound
+	}
+	return f.decrypt(userID, sealed)
+}
+
+// Set will encrypt and store the auth token of the user
+func (f *FileTokenStore) Set(_ context.Context, userID, authToken string) error {
+	if err := validateToken(userID, authToken); err != nil {
+		return err
+	}
+	sealed, err := f.encrypt(userID, authToken)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var tokens map[string]string
+	if tokens, err = f.load(); err != nil {
+		return err
+	}
+	tokens[userID] = sealed
+	return f.save(tokens)
+}
+
+// encrypt will seal the auth token (the user ID is the additional data)
+func (f *FileTokenStore) encrypt(userID, authToken string) (string, error) {
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := f.aead.Seal(nonce, nonce, []byte(authToken), []byte(userID))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt will open the sealed auth token of the user
+func (f *FileTokenStore) decrypt(userID, sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < f.aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted auth token for user: %s", userID)
+	}
+	nonce, ciphertext := data[:f.aead.NonceSize()], data[f.aead.NonceSize():]
+	var authToken []byte
+	if authToken, err = f.aead.Open(nil, nonce, ciphertext, []byte(userID)); err != nil {
+		return "", fmt.Errorf("failed to decrypt auth token for user %s: %w", userID, err)
+	}
+	return string(authToken), nil
+}
+
+// load will read the sealed tokens (keyed by user ID) from the file
+func (f *FileTokenStore) load() (map[string]string, error) {
+	tokens := make(map[string]string)
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	if err = json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+	return tokens, nil
+}
+
+// save will write the sealed tokens to a temporary file and replace the file
+func (f *FileTokenStore) save(tokens map[string]string) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	var tmp *os.File
+	if tmp, err = os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp"); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	// CreateTemp uses owner-only permissions (0600)
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package handcash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUserAuthToken      = "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20"
+	testOtherUserAuthToken = "2122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f40"
+)
+
+// newTestTokenKey returns a file store encryption key
+func newTestTokenKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, tokenKeySize)
+}
+
+// testTokenStore runs the TokenStore tests for the store
+func testTokenStore(t *testing.T, store TokenStore) {
+	ctx := context.Background()
+
+	// Missing token
+	_, err := store.Get(ctx, "user-1")
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+
+	// Invalid tokens
+	assert.Error(t, store.Set(ctx, "", testUserAuthToken))
+	assert.Error(t, store.Set(ctx, "user-1", "not-hex"))
+	assert.Error(t, store.Set(ctx, "user-1", "000000"))
+
+	// Set, replace and get
+	require.NoError(t, store.Set(ctx, "user-1", testOtherUserAuthToken))
+	require.NoError(t, store.Set(ctx, "user-1", testUserAuthToken))
+	require.NoError(t, store.Set(ctx, "user-2", testOtherUserAuthToken))
+
+	var authToken string
+	authToken, err = store.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, testUserAuthToken, authToken)
+
+	authToken, err = store.Get(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, testOtherUserAuthToken, authToken)
+
+	// Delete
+	require.NoError(t, store.Delete(ctx, "user-1"))
+	require.NoError(t, store.Delete(ctx, "user-1"))
+	_, err = store.Get(ctx, "user-1")
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	t.Parallel()
+
+	testTokenStore(t, NewMemoryTokenStore())
+}
+
+func TestFileTokenStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("token store", func(t *testing.T) {
+		store, err := NewFileTokenStore(filepath.Join(t.TempDir(), "tokens.json"), newTestTokenKey(1))
+		require.NoError(t, err)
+		testTokenStore(t, store)
+	})
+
+	t.Run("invalid store", func(t *testing.T) {
+		_, err := NewFileTokenStore("", newTestTokenKey(1))
+		assert.Error(t, err)
+		_, err = NewFileTokenStore("tokens.json", []byte("short"))
+		assert.Error(t, err)
+	})
+
+	t.Run("tokens are encrypted at rest", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tokens.json")
+		store, err := NewFileTokenStore(path, newTestTokenKey(1))
+		require.NoError(t, err)
+		require.NoError(t, store.Set(context.Background(), "user-1", testUserAuthToken))
+
+		var info os.FileInfo
+		info, err = os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		var data []byte
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), testUserAuthToken)
+		assert.Contains(t, string(data), "user-1")
+
+		// A new store with the same key can read the token
+		var reopened *FileTokenStore
+		reopened, err = NewFileTokenStore(path, newTestTokenKey(1))
+		require.NoError(t, err)
+		var authToken string
+		authToken, err = reopened.Get(context.Background(), "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, testUserAuthToken, authToken)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tokens.json")
+		store, err := NewFileTokenStore(path, newTestTokenKey(1))
+		require.NoError(t, err)
+		require.NoError(t, store.Set(context.Background(), "user-1", testUserAuthToken))
+
+		var other *FileTokenStore
+		other, err = NewFileTokenStore(path, newTestTokenKey(2))
+		require.NoError(t, err)
+		_, err = other.Get(context.Background(), "user-1")
+		assert.Error(t, err)
+	})
+
+	t.Run("tokens are bound to the user", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tokens.json")
+		store, err := NewFileTokenStore(path, newTestTokenKey(1))
+		require.NoError(t, err)
+		require.NoError(t, store.Set(context.Background(), "user-1", testUserAuthToken))
+
+		// Copy the sealed token of user-1 to user-2
+		var data []byte
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		tokens := make(map[string]string)
+		require.NoError(t, json.Unmarshal(data, &tokens))
+		tokens["user-2"] = tokens["user-1"]
+		data, err = json.Marshal(tokens)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		_, err = store.Get(context.Background(), "user-2")
+		assert.Error(t, err)
+	})
+
+	t.Run("corrupted file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tokens.json")
+		require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+		store, err := NewFileTokenStore(path, newTestTokenKey(1))
+		require.NoError(t, err)
+		_, err = store.Get(context.Background(), "user-1")
+		assert.Error(t, err)
+		assert.Error(t, store.Set(context.Background(), "user-1", testUserAuthToken))
+	})
+}
@@ -0,0 +1,58 @@
+package handcash
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation name used for all spans
+const tracerName = "github.com/tonicpow/go-handcash-connect"
+
+// Span attributes (auth tokens and signatures are never recorded)
+const (
+	attributeEndpoint      = "handcash.endpoint"
+	attributeEnvironment   = "handcash.environment"
+	attributeRetryCount    = "handcash.retry_count"
+	attributeSatoshiAmount = "handcash.satoshi_amount"
+	attributeStatusCode    = "http.response.status_code"
+	attributeTransactionID = "handcash.transaction_id"
+)
+
+// startSpan will start a new span for the client method
+func (c *Client) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	tracer := c.tracer
+	if tracer == nil {
+		tracer = trace.NewNoopTracerProvider().Tracer(tracerName)
+	}
+
+	var environment string
+	if c.Environment != nil {
+		environment = c.Environment.Environment
+	}
+
+	return tracer.Start(
+		ctx, "handcash."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String(attributeEnvironment, environment)),
+	)
+}
+
+// endSpan will record the error (if any) and end the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// setPaymentAttributes will add the payment details to the span
+func setPaymentAttributes(span trace.Span, payment *PaymentResponse) {
+	span.SetAttributes(
+		attribute.String(attributeTransactionID, payment.TransactionID),
+		attribute.Int64(attributeSatoshiAmount, int64(payment.SatoshiAmount)),
+	)
+}
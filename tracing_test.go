@@ -0,0 +1,125 @@
+package handcash
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestTracingClient returns a client with tracing enabled (using a custom HTTP interface)
+func newTestTracingClient(httpClient httpInterface) (*Client, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	options := DefaultClientOptions()
+	options.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := NewClient(options, nil, EnvironmentBeta)
+	if httpClient != nil {
+		client.httpClient = httpClient
+	}
+	return client, recorder
+}
+
+// spanAttributes will return the span attributes as a map
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	return attributes
+}
+
+func TestClient_Tracing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get profile span", func(t *testing.T) {
+		client, recorder := newTestTracingClient(&mockHTTPGetProfile{})
+		_, err := client.GetProfile(context.Background(), "000000")
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Equal(t, 1, len(spans))
+		assert.Equal(t, "handcash.GetProfile", spans[0].Name())
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+		attributes := spanAttributes(spans[0])
+		assert.Equal(t, EnvironmentBeta, attributes[attributeEnvironment].AsString())
+		assert.Equal(t, endpointProfileCurrent, attributes[attributeEndpoint].AsString())
+		assert.Equal(t, int64(http.StatusOK), attributes[attributeStatusCode].AsInt64())
+		assert.Equal(t, int64(0), attributes[attributeRetryCount].AsInt64())
+
+		// Never record secrets
+		for _, kv := range spans[0].Attributes() {
+			assert.NotContains(t, strings.ToLower(string(kv.Key)), "token")
+			assert.NotContains(t, strings.ToLower(string(kv.Key)), "signature")
+		}
+	})
+
+	t.Run("pay span", func(t *testing.T) {
+		client, recorder := newTestTracingClient(&mockHTTPPay{})
+		_, err := client.Pay(context.Background(), "000000", &PayParameters{
+			Receivers: []*Payment{{Amount: 0.01, CurrencyCode: CurrencyUSD, To: "mrz@moneybutton.com"}},
+		})
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Equal(t, 1, len(spans))
+		assert.Equal(t, "handcash.Pay", spans[0].Name())
+
+		attributes := spanAttributes(spans[0])
+		assert.Equal(t, endpointGetPayRequest, attributes[attributeEndpoint].AsString())
+		assert.Equal(t,
+			"05d7df52a1c58cabada16709469e6940342cb13e8cfa3c7e1438d7ea84765787",
+			attributes[attributeTransactionID].AsString(),
+		)
+		assert.Equal(t, int64(5372), attributes[attributeSatoshiAmount].AsInt64())
+	})
+
+	t.Run("error span", func(t *testing.T) {
+		client, recorder := newTestTracingClient(&mockHTTPBadRequest{})
+		_, err := client.GetSpendableBalance(context.Background(), "000000", CurrencyUSD)
+		require.Error(t, err)
+
+		spans := recorder.Ended()
+		require.Equal(t, 1, len(spans))
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Equal(t, "bad request", spans[0].Status().Description)
+		assert.Equal(t, int64(http.StatusBadRequest), spanAttributes(spans[0])[attributeStatusCode].AsInt64())
+	})
+
+	t.Run("retry count", func(t *testing.T) {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if atomic.AddInt32(&requests, 1) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte(`{"publicProfile":{"id":"1234567","handle":"MisterZ"}}`))
+		}))
+		defer server.Close()
+
+		client, recorder := newTestTracingClient(nil)
+		client.Environment = &Environment{APIURL: server.URL, Environment: "test"}
+		_, err := client.GetProfile(context.Background(), "000000")
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Equal(t, 1, len(spans))
+		assert.Equal(t, int64(1), spanAttributes(spans[0])[attributeRetryCount].AsInt64())
+	})
+
+	t.Run("tracing disabled", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetProfile{}, EnvironmentBeta)
+		profile, err := client.GetProfile(context.Background(), "000000")
+		assert.NoError(t, err)
+		assert.NotNil(t, profile)
+	})
+}
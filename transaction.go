@@ -0,0 +1,204 @@
+package handcash
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// ErrTransactionMismatch is returned when the raw transaction does not match the payment
+var ErrTransactionMismatch = errors.New("transaction does not match the payment")
+
+// DecodeTransaction will parse the raw transaction of the payment into inputs and outputs
+func (p *PaymentResponse) DecodeTransaction() (*bt.Tx, error) {
+	if len(p.RawTransactionHex) == 0 {
+		return nil, fmt.Errorf("missing raw transaction")
+	}
+	tx, err := bt.NewTxFromString(p.RawTransactionHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode raw transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// VerifyTransaction will verify the raw transaction of the payment, rather than trusting the JSON
+//
+// It checks that the hash of the raw transaction is the TransactionID, that the outputs
+// paying each receiver total its satoshis (and all the receivers total the SatoshiAmount)
+// and that the OP_RETURN output matches the attachment that was sent.
+// The attachment check is skipped if the attachment is nil.
+func (p *PaymentResponse) VerifyTransaction(receivers []*TransactionOutput,
+	attachment *Attachment) (*bt.Tx, error) {
+
+	// Make sure we have receivers
+	if len(receivers) == 0 {
+		return nil, fmt.Errorf("missing receivers")
+	}
+
+	tx, err := p.verifyTransactionID()
+	if err != nil {
+		return nil, err
+	}
+
+	// The receivers are paid the satoshi amount
+	if err = verifyOutputs(tx, receivers, p.SatoshiAmount); err != nil {
+		return nil, err
+	}
+
+	// The attachment is in the OP_RETURN
+	if attachment != nil {
+		if err = verifyAttachment(tx, attachment); err != nil {
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// verifyTransactionID will decode the raw transaction and check it hashes to the TransactionID
+func (p *PaymentResponse) verifyTransactionID() (*bt.Tx, error) {
+	tx, err := p.DecodeTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	// The transaction ID is the hash of the raw transaction (not the JSON)
+	raw, _ := hex.DecodeString(p.RawTransactionHex)
+	if txID := hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(raw))); txID != p.TransactionID {
+		return nil, fmt.Errorf("%w: transaction id is %s, expected %s", ErrTransactionMismatch, txID, p.TransactionID)
+	}
+	return tx, nil
+}
+
+// verifyOutputs will check that the outputs paying each receiver total its satoshis
+// and that the receivers total the satoshi amount
+func verifyOutputs(tx *bt.Tx, receivers []*TransactionOutput, amount uint64) error {
+
+	// Total the expected satoshis per locking script
+	expected := make(map[string]uint64, len(receivers))
+	var total uint64
+	for i, receiver := range receivers {
+		if receiver == nil {
+			return fmt.Errorf("missing receiver at index %d", i)
+		}
+		script, err := receiverLockingScript(receiver)
+		if err != nil {
+			return fmt.Errorf("invalid receiver at index %d: %w", i, err)
+		}
+		expected[script] += receiver.Satoshis
+		total += receiver.Satoshis
+	}
+	if total != amount {
+		return fmt.Errorf("%w: receivers total %d satoshis, expected %d", ErrTransactionMismatch, total, amount)
+	}
+
+	// Total the outputs paying each receiver
+	paid := make(map[string]uint64, len(expected))
+	for _, output := range tx.Outputs {
+		if script := output.LockingScript.String(); hasScript(expected, script) {
+			paid[script] += output.Satoshis
+		}
+	}
+	for script, satoshis := range expected {
+		if paid[script] != satoshis {
+			return fmt.Errorf(
+				"%w: outputs to %s pay %d satoshis, expected %d", ErrTransactionMismatch, script, paid[script], satoshis,
+			)
+		}
+	}
+	return nil
+}
+
+// hasScript will return true if the locking script is expected
+func hasScript(expected map[string]uint64, script string) bool {
+	_, ok := expected[script]
+	return ok
+}
+
+// receiverLockingScript will return the locking script (hex) of the receiver
+func receiverLockingScript(receiver *TransactionOutput) (string, error) {
+	if len(receiver.LockingScript) > 0 {
+		script, err := bscript.NewFromHexString(receiver.LockingScript)
+		if err != nil {
+			return "", err
+		}
+		return script.String(), nil
+	} else if len(receiver.Address) > 0 {
+		script, err := bscript.NewP2PKHFromAddress(receiver.Address)
+		if err != nil {
+			return "", err
+		}
+		return script.String(), nil
+	}
+	return "", fmt.Errorf("missing address or locking script")
+}
+
+// verifyAttachment will check that an OP_RETURN output contains the attachment
+func verifyAttachment(tx *bt.Tx, attachment *Attachment) error {
+	expected, err := attachmentData(attachment)
+	if err != nil {
+		return err
+	}
+
+	for _, output := range tx.Outputs {
+		if !output.LockingScript.IsData() {
+			continue
+		}
+		for _, data := range outputData(output.LockingScript) {
+			if attachment.Format == AttachmentFormatJSON {
+				if jsonEqual(data, expected) {
+					return nil
+				}
+			} else if bytes.Equal(data, expected) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: attachment not found in OP_RETURN", ErrTransactionMismatch)
+}
+
+// attachmentData will return the attachment as the bytes expected in the OP_RETURN
+func attachmentData(attachment *Attachment) ([]byte, error) {
+	if attachment.Format == AttachmentFormatJSON {
+		return json.Marshal(attachment.Value)
+	}
+
+	value, ok := attachment.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("attachment value must be a string for the %s format", attachment.Format)
+	}
+	switch attachment.Format {
+	case AttachmentFormatBase64:
+		return base64.StdEncoding.DecodeString(value)
+	case AttachmentFormatHex:
+		return hex.DecodeString(value)
+	default:
+		return nil, fmt.Errorf("unsupported attachment format: %s", attachment.Format)
+	}
+}
+
+// outputData will return the data pushed after the OP_RETURN
+func outputData(script *bscript.Script) [][]byte {
+	b := []byte(*script)
+	if len(b) > 0 && b[0] == bscript.OpFALSE {
+		b = b[1:]
+	}
+	parts, _ := bscript.DecodeParts(b[1:])
+	return parts
+}
+
+// jsonEqual will return true if both are the same JSON value (ignoring key order and spacing)
+func jsonEqual(a, b []byte) bool {
+	var valueA, valueB interface{}
+	if json.Unmarshal(a, &valueA) != nil || json.Unmarshal(b, &valueB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(valueA, valueB)
+}
@@ -0,0 +1,168 @@
+package handcash
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRawTransactionHex is the raw transaction from the pay response
+// (an OP_RETURN of {"some":"data"}, 5372 satoshis to the receiver and 599 satoshis change)
+const testRawTransactionHex = "01000000018598fbea559e4a59772361994f800adb63bab592e276de7ebd5805ecc639b3b8010000006a47304402200fc98489e2bbba5cb7f8cea970c0037585d42618ef60d172179307b4446854a802206be468ffd31f97c6e01a6549be50241d42633e32ba4e06ff4b2565ec897232a2412103c1fbc71737d3820890535112ac99b2471d6bacbd8a7e7825c65863a67b1d0c7effffffff03000000000000000012006a0f7b22736f6d65223a2264617461227dfc140000000000001976a914b7ce7a4c1350f1cb9dcaecca10d48f064be9197f88ac57020000000000001976a9145233794b8bdf2fd7f809b11da081189d2e79000c88ac00000000"
+
+// testReceiverLockingScript is the locking script of the receiver output (5372 satoshis)
+const testReceiverLockingScript = "76a914b7ce7a4c1350f1cb9dcaecca10d48f064be9197f88ac"
+
+// testChangeLockingScript is the locking script of the change output (599 satoshis)
+const testChangeLockingScript = "76a9145233794b8bdf2fd7f809b11da081189d2e79000c88ac"
+
+// newTestTransactionOutputs returns the expected receiver output of the raw transaction
+func newTestTransactionOutputs() []*TransactionOutput {
+	return []*TransactionOutput{{LockingScript: testReceiverLockingScript, Satoshis: 5372}}
+}
+
+// newTestPaymentResponse returns the payment response for the raw transaction
+func newTestPaymentResponse() *PaymentResponse {
+	return &PaymentResponse{
+		RawTransactionHex: testRawTransactionHex,
+		SatoshiAmount:     5372,
+		TransactionID:     "05d7df52a1c58cabada16709469e6940342cb13e8cfa3c7e1438d7ea84765787",
+	}
+}
+
+func TestPaymentResponse_DecodeTransaction(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid transaction", func(t *testing.T) {
+		tx, err := newTestPaymentResponse().DecodeTransaction()
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(tx.Inputs))
+		require.Equal(t, 3, len(tx.Outputs))
+		assert.True(t, tx.Outputs[0].LockingScript.IsData())
+		assert.Equal(t, uint64(5372), tx.Outputs[1].Satoshis)
+		assert.Equal(t, uint64(599), tx.Outputs[2].Satoshis)
+	})
+
+	t.Run("missing raw transaction", func(t *testing.T) {
+		tx, err := (&PaymentResponse{}).DecodeTransaction()
+		assert.Error(t, err)
+		assert.Nil(t, tx)
+	})
+
+	t.Run("invalid raw transaction", func(t *testing.T) {
+		tx, err := (&PaymentResponse{RawTransactionHex: "01000000zz"}).DecodeTransaction()
+		assert.Error(t, err)
+		assert.Nil(t, tx)
+	})
+}
+
+func TestPaymentResponse_VerifyTransaction(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{"some":"data"}`)
+
+	t.Run("valid payment", func(t *testing.T) {
+		for _, attachment := range []*Attachment{
+			nil,
+			{Format: AttachmentFormatJSON, Value: map[string]string{"some": "data"}},
+			{Format: AttachmentFormatHex, Value: hex.EncodeToString(data)},
+			{Format: AttachmentFormatBase64, Value: base64.StdEncoding.EncodeToString(data)},
+		} {
+			tx, err := newTestPaymentResponse().VerifyTransaction(newTestTransactionOutputs(), attachment)
+			require.NoError(t, err)
+			assert.NotNil(t, tx)
+		}
+	})
+
+	t.Run("receiver address", func(t *testing.T) {
+		hash, _ := hex.DecodeString("b7ce7a4c1350f1cb9dcaecca10d48f064be9197f")
+		address, err := bscript.NewAddressFromPublicKeyHash(hash, true)
+		require.NoError(t, err)
+
+		_, err = newTestPaymentResponse().VerifyTransaction(
+			[]*TransactionOutput{{Address: address.AddressString, Satoshis: 5372}}, nil,
+		)
+		assert.NoError(t, err)
+	})
+
+	t.Run("transaction id mismatch", func(t *testing.T) {
+		payment := newTestPaymentResponse()
+		payment.TransactionID = "00d7df52a1c58cabada16709469e6940342cb13e8cfa3c7e1438d7ea84765787"
+		_, err := payment.VerifyTransaction(newTestTransactionOutputs(), nil)
+		assert.ErrorIs(t, err, ErrTransactionMismatch)
+	})
+
+	t.Run("satoshi amount mismatch", func(t *testing.T) {
+		payment := newTestPaymentResponse()
+		payment.SatoshiAmount = 5000
+		_, err := payment.VerifyTransaction(newTestTransactionOutputs(), nil)
+		assert.ErrorIs(t, err, ErrTransactionMismatch)
+
+		// The receiver is not paid the expected satoshis
+		payment.SatoshiAmount = 5000
+		_, err = payment.VerifyTransaction([]*TransactionOutput{
+			{LockingScript: testReceiverLockingScript, Satoshis: 5000},
+		}, nil)
+		assert.ErrorIs(t, err, ErrTransactionMismatch)
+	})
+
+	t.Run("wrong receiver", func(t *testing.T) {
+
+		// The amount matches an output, but not the one to the receiver
+		payment := newTestPaymentResponse()
+		payment.SatoshiAmount = 599
+		_, err := payment.VerifyTransaction([]*TransactionOutput{
+			{LockingScript: "76a914000000000000000000000000000000000000000088ac", Satoshis: 599},
+		}, nil)
+		assert.ErrorIs(t, err, ErrTransactionMismatch)
+
+		// Both outputs (a receiver can be paid by the change output)
+		payment.SatoshiAmount = 5372 + 599
+		_, err = payment.VerifyTransaction([]*TransactionOutput{
+			{LockingScript: testReceiverLockingScript, Satoshis: 5372},
+			{LockingScript: testChangeLockingScript, Satoshis: 599},
+		}, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid receivers", func(t *testing.T) {
+		for _, receivers := range [][]*TransactionOutput{
+			nil,
+			{nil},
+			{{Satoshis: 5372}},
+			{{LockingScript: "zz", Satoshis: 5372}},
+			{{Address: "not-an-address", Satoshis: 5372}},
+		} {
+			_, err := newTestPaymentResponse().VerifyTransaction(receivers, nil)
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, ErrTransactionMismatch)
+		}
+	})
+
+	t.Run("attachment mismatch", func(t *testing.T) {
+		for _, attachment := range []*Attachment{
+			{Format: AttachmentFormatJSON, Value: map[string]string{"some": "other"}},
+			{Format: AttachmentFormatHex, Value: "deadbeef"},
+		} {
+			_, err := newTestPaymentResponse().VerifyTransaction(newTestTransactionOutputs(), attachment)
+			assert.ErrorIs(t, err, ErrTransactionMismatch)
+		}
+	})
+
+	t.Run("invalid attachment", func(t *testing.T) {
+		_, err := newTestPaymentResponse().VerifyTransaction(
+			newTestTransactionOutputs(), &Attachment{Format: AttachmentFormatHex, Value: 123},
+		)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrTransactionMismatch)
+
+		_, err = newTestPaymentResponse().VerifyTransaction(
+			newTestTransactionOutputs(), &Attachment{Format: "xml", Value: "<a/>"},
+		)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,159 @@
+package handcash
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError is a single invalid field
+type ValidationError struct {
+	Field   string `json:"field"`   // Field is the JSON path (IE: receivers[0].amount)
+	Message string `json:"message"` // Message explains the problem
+}
+
+// Error will return the field and the problem
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors is every problem found by Validate()
+type ValidationErrors []*ValidationError
+
+// Error will return all the problems in one message
+func (v ValidationErrors) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, err := range v {
+		messages = append(messages, err.Error())
+	}
+	return "invalid payment parameters: " + strings.Join(messages, "; ")
+}
+
+// Unwrap will return the problems (for errors.As and errors.Is)
+func (v ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(v))
+	for _, err := range v {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+var (
+
+	// handleRegExp matches a HandCash handle (with an optional $ prefix)
+	handleRegExp = regexp.MustCompile(`^\$?[a-zA-Z0-9_.-]{1,50}$`)
+
+	// paymailRegExp matches a paymail (alias@domain.tld)
+	paymailRegExp = regexp.MustCompile(`^[a-zA-Z0-9_.+-]+@[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)+$`)
+
+	// validCurrencies are the supported currency codes
+	validCurrencies = map[CurrencyCode]bool{
+		CurrencyARS: true, CurrencyAUD: true, CurrencyBRL: true, CurrencyBSV: true,
+		CurrencyCAD: true, CurrencyCHF: true, CurrencyCNY: true, CurrencyCOP: true,
+		CurrencyCZK: true, CurrencyDKK: true, CurrencyEUR: true, CurrencyGBP: true,
+		CurrencyHKD: true, CurrencyJPY: true, CurrencyMXN: true, CurrencyNOK: true,
+		CurrencyNZD: true, CurrencyPHP: true, CurrencyRUB: true, CurrencySAT: true,
+		CurrencySEK: true, CurrencySGD: true, CurrencyTHB: true, CurrencyUSD: true,
+		CurrencyZAR: true,
+	}
+)
+
+// Validate will check the payment parameters before they are sent
+//
+// Every problem is returned as ValidationErrors (nil if valid)
+func (p *PayParameters) Validate() error {
+	if p == nil {
+		return ValidationErrors{newValidationError("receivers", "at least one receiver is required")}
+	}
+	var errs ValidationErrors
+
+	// Receivers
+	if len(p.Receivers) == 0 {
+		errs = append(errs, newValidationError("receivers", "at least one receiver is required"))
+	}
+	for i, receiver := range p.Receivers {
+		errs = append(errs, validateReceiver(fmt.Sprintf("receivers[%d]", i), receiver)...)
+	}
+
+	// Description and attachment
+	errs = append(errs, validatePaymentDetails(p.Description, p.Attachment)...)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// newValidationError will return the problem for the field
+func newValidationError(field, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// validateReceiver will return the problems with a single receiver (field is its path)
+func validateReceiver(field string, receiver *Payment) (errs ValidationErrors) {
+	if receiver == nil {
+		return ValidationErrors{newValidationError(field, "missing receiver")}
+	}
+	if !validCurrencies[receiver.CurrencyCode] {
+		errs = append(errs, newValidationError(field+".currencyCode", "unsupported currency code: %q", receiver.CurrencyCode))
+	}
+	if math.IsNaN(receiver.Amount) || math.IsInf(receiver.Amount, 0) || receiver.Amount <= 0 {
+		errs = append(errs, newValidationError(field+".amount", "must be greater than zero"))
+	}
+	if len(receiver.To) == 0 {
+		errs = append(errs, newValidationError(field+".to", "missing handle or paymail"))
+	} else if !handleRegExp.MatchString(receiver.To) && !paymailRegExp.MatchString(receiver.To) {
+		errs = append(errs, newValidationError(field+".to", "invalid handle or paymail: %q", receiver.To))
+	}
+	return
+}
+
+// validatePaymentDetails will return the problems with the description and attachment
+func validatePaymentDetails(description string, attachment *Attachment) (errs ValidationErrors) {
+	if length := utf8.RuneCountInString(description); length > maxDescriptionLength {
+		errs = append(errs, newValidationError(
+			"description", "must be at most %d characters (got %d)", maxDescriptionLength, length,
+		))
+	}
+	if attachment != nil {
+		if field, message := validateAttachment(attachment); len(message) > 0 {
+			errs = append(errs, newValidationError("attachment."+field, "%s", message))
+		}
+	}
+	return
+}
+
+// validateAttachment will return the invalid field and the problem (empty if valid)
+func validateAttachment(attachment *Attachment) (field, message string) {
+	switch attachment.Format {
+	case AttachmentFormatBase64, AttachmentFormatHex, AttachmentFormatJSON:
+	default:
+		return "format", fmt.Sprintf("unsupported format: %q", attachment.Format)
+	}
+
+	if attachment.Value == nil {
+		return "value", "missing value"
+	} else if attachment.Format == AttachmentFormatJSON {
+		return "", ""
+	}
+
+	// Base64 and hex values must be encoded strings
+	value, ok := attachment.Value.(string)
+	if !ok {
+		return "value", fmt.Sprintf("must be a string for the %s format", attachment.Format)
+	}
+	var err error
+	if attachment.Format == AttachmentFormatHex {
+		_, err = hex.DecodeString(value)
+	} else {
+		_, err = base64.StdEncoding.DecodeString(value)
+	}
+	if err != nil {
+		return "value", fmt.Sprintf("not valid %s", attachment.Format)
+	}
+	return "", ""
+}
@@ -0,0 +1,117 @@
+package handcash
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validationFields will return the invalid fields of the validation error
+func validationFields(t *testing.T, err error) []string {
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	fields := make([]string, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+func TestPayParameters_Validate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid parameters", func(t *testing.T) {
+		for _, params := range []*PayParameters{
+			{Receivers: []*Payment{{Amount: 0.01, CurrencyCode: CurrencyUSD, To: "mrz@moneybutton.com"}}},
+			{Receivers: []*Payment{{Amount: 500, CurrencyCode: CurrencySAT, To: "MisterZ"}}},
+			{Receivers: []*Payment{{Amount: 1, CurrencyCode: CurrencyBSV, To: "$mr_z"}}},
+			{
+				Attachment:  &Attachment{Format: AttachmentFormatJSON, Value: map[string]string{"some": "data"}},
+				Description: "Thanks dude!",
+				Receivers:   []*Payment{{Amount: 0.01, CurrencyCode: CurrencyEUR, To: "MisterZ"}},
+			},
+			{
+				Attachment: &Attachment{Format: AttachmentFormatHex, Value: "deadbeef"},
+				Receivers:  []*Payment{{Amount: 0.01, CurrencyCode: CurrencyEUR, To: "MisterZ"}},
+			},
+			{
+				Attachment: &Attachment{Format: AttachmentFormatBase64, Value: "aGVsbG8="},
+				Receivers:  []*Payment{{Amount: 0.01, CurrencyCode: CurrencyEUR, To: "MisterZ"}},
+			},
+		} {
+			assert.NoError(t, params.Validate())
+		}
+	})
+
+	t.Run("every problem is listed", func(t *testing.T) {
+		params := &PayParameters{
+			Attachment:  &Attachment{Format: AttachmentFormatHex, Value: "not-hex"},
+			Description: strings.Repeat("a", maxDescriptionLength+1),
+			Receivers: []*Payment{
+				{Amount: 0, CurrencyCode: "XYZ", To: "not a handle"},
+				nil,
+				{Amount: -1, CurrencyCode: CurrencyUSD, To: ""},
+				{Amount: math.NaN(), CurrencyCode: CurrencyUSD, To: "bad@paymail"},
+			},
+		}
+
+		err := params.Validate()
+		require.Error(t, err)
+		assert.Equal(t, []string{
+			"receivers[0].currencyCode",
+			"receivers[0].amount",
+			"receivers[0].to",
+			"receivers[1]",
+			"receivers[2].amount",
+			"receivers[2].to",
+			"receivers[3].amount",
+			"receivers[3].to",
+			"description",
+			"attachment.value",
+		}, validationFields(t, err))
+		assert.Contains(t, err.Error(), "receivers[0].currencyCode: unsupported currency code: \"XYZ\"")
+	})
+
+	t.Run("missing receivers", func(t *testing.T) {
+		err := (&PayParameters{}).Validate()
+		assert.Equal(t, []string{"receivers"}, validationFields(t, err))
+	})
+
+	t.Run("nil parameters", func(t *testing.T) {
+		var params *PayParameters
+		err := params.Validate()
+		assert.Equal(t, []string{"receivers"}, validationFields(t, err))
+	})
+
+	t.Run("attachment mismatches", func(t *testing.T) {
+		receivers := []*Payment{{Amount: 1, CurrencyCode: CurrencySAT, To: "MisterZ"}}
+		for _, attachment := range []*Attachment{
+			{Format: "xml", Value: "<a/>"},
+			{Format: AttachmentFormatJSON},
+			{Format: AttachmentFormatBase64, Value: map[string]string{"some": "data"}},
+			{Format: AttachmentFormatBase64, Value: "%%%"},
+		} {
+			err := (&PayParameters{Attachment: attachment, Receivers: receivers}).Validate()
+			fields := validationFields(t, err)
+			require.Equal(t, 1, len(fields))
+			assert.True(t, strings.HasPrefix(fields[0], "attachment."))
+		}
+	})
+
+	t.Run("pay validates before sending", func(t *testing.T) {
+		mock := &mockHTTPCountingPay{}
+		client := newTestClient(mock, EnvironmentBeta)
+		payment, err := client.Pay(context.Background(), "000000", &PayParameters{
+			Receivers: []*Payment{{Amount: 0, CurrencyCode: CurrencyUSD, To: "MisterZ"}},
+		})
+		require.Error(t, err)
+		assert.Nil(t, payment)
+		assert.Equal(t, []string{"receivers[0].amount"}, validationFields(t, err))
+		assert.Equal(t, int32(0), mock.requests)
+	})
+}
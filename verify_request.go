@@ -0,0 +1,206 @@
+package handcash
+
+import (
+	"bytes"
+	"container/heap"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/libsv/go-bk/bec"
+)
+
+var (
+
+	// ErrReplayCacheFull is returned when the replay cache cannot remember another request
+	// (requests are rejected until some expire, so a flood cannot evict a captured request)
+	ErrReplayCacheFull = errors.New("replay cache is full")
+
+	// ErrReplayedRequest is returned when a signed request has already been seen
+	ErrReplayedRequest = errors.New("replayed request")
+
+	// ErrStaleTimestamp is returned when the request timestamp is outside the allowed skew
+	ErrStaleTimestamp = errors.New("stale request timestamp")
+
+	// defaultReplayCache is used by VerifySignedRequest()
+	defaultReplayCache = NewMemoryReplayCache(defaultReplayCacheSize)
+)
+
+// defaultReplayCacheSize is the max number of requests remembered by the default replay cache
+const defaultReplayCacheSize = 100000
+
+// VerifySignedRequest will verify the oAuth headers of a signed HandCash Connect request
+//
+// The signature hash is recomputed from the method, path, timestamp and body, and the DER
+// signature is checked against the oauth-publickey header. Requests with a timestamp
+// further than maxSkew from now, or already seen, are rejected (maxSkew must be greater
+// than zero). The body is restored so the request can still be forwarded. Seen requests
+// are kept in a process-wide bounded cache, use VerifySignedRequestWithCache() to provide
+// a cache.
+func VerifySignedRequest(req *http.Request, maxSkew time.Duration) error {
+	return VerifySignedRequestWithCache(req, maxSkew, defaultReplayCache)
+}
+
+// VerifySignedRequestWithCache will verify the signed request (see VerifySignedRequest)
+// using the cache to reject replayed requests (the default cache is used if nil)
+func VerifySignedRequestWithCache(req *http.Request, maxSkew time.Duration, cache ReplayCache) error {
+
+	// Make sure we have a request and a max skew
+	if req == nil {
+		return fmt.Errorf("missing request")
+	} else if maxSkew <= 0 {
+		return fmt.Errorf("max skew must be greater than zero")
+	}
+
+	// Get the headers
+	publicKeyHex := req.Header.Get("oauth-publickey")
+	signatureHex := req.Header.Get("oauth-signature")
+	timestamp := req.Header.Get("oauth-timestamp")
+	if len(publicKeyHex) == 0 || len(signatureHex) == 0 || len(timestamp) == 0 {
+		return fmt.Errorf("missing oauth headers")
+	}
+
+	// Check the timestamp
+	requestTime, err := time.Parse(isoFormat, timestamp)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+	if skew := time.Since(requestTime); skew > maxSkew || skew < -maxSkew {
+		return ErrStaleTimestamp
+	}
+
+	// Parse the public key and signature
+	var publicKeyBytes []byte
+	if publicKeyBytes, err = hex.DecodeString(publicKeyHex); err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	var publicKey *bec.PublicKey
+	if publicKey, err = bec.ParsePubKey(publicKeyBytes, bec.S256()); err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	var signatureBytes []byte
+	if signatureBytes, err = hex.DecodeString(signatureHex); err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	var signature *bec.Signature
+	if signature, err = bec.ParseDERSignature(signatureBytes, bec.S256()); err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	// Read the body (and put it back)
+	bodyString := emptyBody
+	if req.Body != nil {
+		var body []byte
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return fmt.Errorf("failed to read body: %w", err)
+		}
+		_ = req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if len(body) > 0 {
+			bodyString = string(body)
+		}
+	}
+
+	// Verify the signature
+	hash := getSignatureHash(req.Method, req.URL.Path, timestamp, bodyString)
+	if !signature.Verify(hash, publicKey) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	// Check for a replay (only once the signature is valid)
+	//
+	// The key is the signer and the signed content (not the signature, which is malleable)
+	if cache == nil {
+		cache = defaultReplayCache
+	}
+	key := sha256.Sum256(append(publicKey.SerialiseCompressed(), hash...))
+	return cache.Add(hex.EncodeToString(key[:]), requestTime.Add(maxSkew))
+}
+
+// ReplayCache remembers the signed requests already seen by VerifySignedRequestWithCache()
+type ReplayCache interface {
+
+	// Add records the request key until it expires, returning ErrReplayedRequest if it was
+	// already seen (or another error, such as ErrReplayCacheFull, if it cannot be recorded)
+	Add(key string, expires time.Time) error
+}
+
+// MemoryReplayCache is an in-memory ReplayCache holding a max number of requests
+//
+// Keys are only forgotten once they expire: when the cache is full, new requests are
+// rejected with ErrReplayCacheFull (size the cache for the requests expected within maxSkew)
+type MemoryReplayCache struct {
+	expiries   replayHeap
+	maxEntries int
+	mu         sync.Mutex
+	seen       map[string]time.Time
+}
+
+// NewMemoryReplayCache will return a new replay cache holding up to maxEntries requests
+// (the default size is used if maxEntries is not set)
+func NewMemoryReplayCache(maxEntries int) *MemoryReplayCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultReplayCacheSize
+	}
+	return &MemoryReplayCache{maxEntries: maxEntries, seen: make(map[string]time.Time)}
+}
+
+// Add will add the request key to the cache, returning ErrReplayedRequest if it was already seen
+func (r *MemoryReplayCache) Add(key string, expires time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Remove the expired keys (the heap is ordered by expiry)
+	now := time.Now()
+	for len(r.expiries) > 0 && now.After(r.expiries[0].expires) {
+		r.remove(heap.Pop(&r.expiries).(replayEntry))
+	}
+
+	if _, ok := r.seen[key]; ok {
+		return ErrReplayedRequest
+	} else if len(r.seen) >= r.maxEntries {
+		return ErrReplayCacheFull
+	}
+
+	r.seen[key] = expires
+	heap.Push(&r.expiries, replayEntry{expires: expires, key: key})
+	return nil
+}
+
+// remove will delete the key of the entry (if it was not added again since)
+func (r *MemoryReplayCache) remove(entry replayEntry) {
+	if expires, ok := r.seen[entry.key]; ok && expires.Equal(entry.expires) {
+		delete(r.seen, entry.key)
+	}
+}
+
+// replayEntry is a key in the replay heap
+type replayEntry struct {
+	expires time.Time
+	key     string
+}
+
+// replayHeap is a min-heap of keys by expiry (implements heap.Interface)
+type replayHeap []replayEntry
+
+func (h replayHeap) Len() int           { return len(h) }
+func (h replayHeap) Less(i, j int) bool { return h[i].expires.Before(h[j].expires) }
+func (h replayHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+// Push will add an entry
+func (h *replayHeap) Push(x interface{}) {
+	*h = append(*h, x.(replayEntry))
+}
+
+// Pop will remove the last entry
+func (h *replayHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
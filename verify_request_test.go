@@ -0,0 +1,219 @@
+package handcash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDERSignature will return the DER encoding of the signature (without normalizing s)
+func newTestDERSignature(r, s *big.Int) []byte {
+	encodeInt := func(i *big.Int) []byte {
+		b := i.Bytes()
+		if b[0]&0x80 != 0 {
+			b = append([]byte{0x00}, b...)
+		}
+		return append([]byte{0x02, byte(len(b))}, b...)
+	}
+	body := append(encodeInt(r), encodeInt(s)...)
+	return append([]byte{0x30, byte(len(body))}, body...)
+}
+
+// newTestSignedRequest will return a signed http request for the endpoint
+func newTestSignedRequest(t *testing.T, method, endpoint string, body interface{},
+	data []byte, timestamp string) *http.Request {
+
+	client := newTestClient(&mockHTTPDefaultClient{}, EnvironmentBeta)
+
+	// These values are used (GOSEC complains about the token)
+	token := "68d8fadc95324afa853f00923e0b" + "86f06a76ceb7a6afbb1784e0dde8f43989a0"
+
+	signed, err := client.getSignedRequest(method, endpoint, token, body, timestamp)
+	require.NoError(t, err)
+
+	var req *http.Request
+	req, err = http.NewRequest(method, signed.URI, bytes.NewReader(data))
+	require.NoError(t, err)
+	req.Header.Set("oauth-publickey", signed.Headers.OauthPublicKey)
+	req.Header.Set("oauth-signature", signed.Headers.OauthSignature)
+	req.Header.Set("oauth-timestamp", signed.Headers.OauthTimestamp)
+	return req
+}
+
+// verifyTestSignedRequest will verify the request with a new replay cache
+func verifyTestSignedRequest(req *http.Request) error {
+	return VerifySignedRequestWithCache(req, time.Minute, NewMemoryReplayCache(10))
+}
+
+func TestVerifySignedRequest(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid request - empty body", func(t *testing.T) {
+		req := newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, currentISOTimestamp())
+		assert.NoError(t, verifyTestSignedRequest(req))
+	})
+
+	t.Run("valid request - with body", func(t *testing.T) {
+		params := &PaymentRequest{TransactionID: "abc"}
+		req := newTestSignedRequest(
+			t, http.MethodGet, endpointGetPaymentRequest, params, []byte(`{"transactionId":"abc"}`), currentISOTimestamp(),
+		)
+		assert.NoError(t, verifyTestSignedRequest(req))
+
+		// Body is restored
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"transactionId":"abc"}`, string(body))
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		params := &PaymentRequest{TransactionID: "abc"}
+		req := newTestSignedRequest(
+			t, http.MethodGet, endpointGetPaymentRequest, params, []byte(`{"transactionId":"xyz"}`), currentISOTimestamp(),
+		)
+		assert.Error(t, verifyTestSignedRequest(req))
+	})
+
+	t.Run("tampered method", func(t *testing.T) {
+		req := newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, currentISOTimestamp())
+		req.Method = http.MethodPost
+		assert.Error(t, verifyTestSignedRequest(req))
+	})
+
+	t.Run("missing headers", func(t *testing.T) {
+		req := newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, currentISOTimestamp())
+		req.Header.Del("oauth-signature")
+		assert.Error(t, verifyTestSignedRequest(req))
+		assert.Error(t, verifyTestSignedRequest(nil))
+	})
+
+	t.Run("invalid public key", func(t *testing.T) {
+		req := newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, currentISOTimestamp())
+		req.Header.Set("oauth-publickey", "00")
+		assert.Error(t, verifyTestSignedRequest(req))
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		req := newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, currentISOTimestamp())
+		req.Header.Set("oauth-signature", "0000")
+		assert.Error(t, verifyTestSignedRequest(req))
+	})
+
+	t.Run("max skew is required", func(t *testing.T) {
+		req := newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, currentISOTimestamp())
+		assert.Error(t, VerifySignedRequest(req, 0))
+		assert.Error(t, VerifySignedRequest(req, -time.Minute))
+	})
+
+	t.Run("stale timestamp", func(t *testing.T) {
+		req := newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, testTimestamp)
+		assert.ErrorIs(t, VerifySignedRequest(req, time.Minute), ErrStaleTimestamp)
+	})
+
+	t.Run("future timestamp", func(t *testing.T) {
+		timestamp := time.Now().Add(time.Hour).UTC().Format(isoFormat)
+		req := newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, timestamp)
+		assert.ErrorIs(t, VerifySignedRequest(req, time.Minute), ErrStaleTimestamp)
+	})
+
+	t.Run("invalid timestamp", func(t *testing.T) {
+		req := newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, "yesterday")
+		assert.Error(t, VerifySignedRequest(req, time.Minute))
+	})
+
+	t.Run("replayed request", func(t *testing.T) {
+		timestamp := time.Now().Add(-time.Second).UTC().Format(isoFormat)
+		params := &BalanceRequest{CurrencyCode: CurrencyUSD}
+		data := []byte(`{"currencyCode":"USD"}`)
+
+		req := newTestSignedRequest(t, http.MethodGet, endpointGetSpendableBalanceRequest, params, data, timestamp)
+		assert.NoError(t, VerifySignedRequest(req, time.Minute))
+
+		req = newTestSignedRequest(t, http.MethodGet, endpointGetSpendableBalanceRequest, params, data, timestamp)
+		assert.ErrorIs(t, VerifySignedRequest(req, time.Minute), ErrReplayedRequest)
+	})
+
+	t.Run("replayed request with a malleated signature", func(t *testing.T) {
+		cache := NewMemoryReplayCache(10)
+		timestamp := time.Now().Add(-time.Second).UTC().Format(isoFormat)
+		req := newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, timestamp)
+		signatureHex := req.Header.Get("oauth-signature")
+		assert.NoError(t, VerifySignedRequestWithCache(req, time.Minute, cache))
+
+		// Replace s with n - s (still a valid signature)
+		sigBytes, err := hex.DecodeString(signatureHex)
+		require.NoError(t, err)
+		var sig *bec.Signature
+		sig, err = bec.ParseDERSignature(sigBytes, bec.S256())
+		require.NoError(t, err)
+		highS := new(big.Int).Sub(bec.S256().N, sig.S)
+		malleated := newTestDERSignature(sig.R, highS)
+		assert.NotEqual(t, signatureHex, hex.EncodeToString(malleated))
+
+		req = newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, timestamp)
+		req.Header.Set("oauth-signature", hex.EncodeToString(malleated))
+		assert.ErrorIs(t, VerifySignedRequestWithCache(req, time.Minute, cache), ErrReplayedRequest)
+	})
+
+	t.Run("full cache rejects requests", func(t *testing.T) {
+		cache := NewMemoryReplayCache(1)
+		timestamp := time.Now().Add(-time.Second).UTC().Format(isoFormat)
+		req := newTestSignedRequest(t, http.MethodGet, endpointGetFriends, nil, nil, timestamp)
+		assert.NoError(t, VerifySignedRequestWithCache(req, time.Minute, cache))
+
+		// A new request cannot evict the first one
+		req = newTestSignedRequest(t, http.MethodGet, endpointProfileCurrent, nil, nil, timestamp)
+		assert.ErrorIs(t, VerifySignedRequestWithCache(req, time.Minute, cache), ErrReplayCacheFull)
+
+		req = newTestSignedRequest(t, http.MethodGet, endpointGetFriends, nil, nil, timestamp)
+		assert.ErrorIs(t, VerifySignedRequestWithCache(req, time.Minute, cache), ErrReplayedRequest)
+	})
+
+	t.Run("separate caches", func(t *testing.T) {
+		timestamp := time.Now().Add(-time.Second).UTC().Format(isoFormat)
+		req := newTestSignedRequest(t, http.MethodGet, endpointGetFriends, nil, nil, timestamp)
+		assert.NoError(t, VerifySignedRequestWithCache(req, time.Minute, NewMemoryReplayCache(10)))
+
+		req = newTestSignedRequest(t, http.MethodGet, endpointGetFriends, nil, nil, timestamp)
+		assert.NoError(t, VerifySignedRequestWithCache(req, time.Minute, NewMemoryReplayCache(10)))
+	})
+}
+
+func TestMemoryReplayCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("seen keys", func(t *testing.T) {
+		cache := NewMemoryReplayCache(0)
+		assert.Equal(t, defaultReplayCacheSize, cache.maxEntries)
+		assert.NoError(t, cache.Add("key-1", time.Now().Add(time.Minute)))
+		assert.ErrorIs(t, cache.Add("key-1", time.Now().Add(time.Minute)), ErrReplayedRequest)
+	})
+
+	t.Run("expired keys are removed", func(t *testing.T) {
+		cache := NewMemoryReplayCache(10)
+		assert.NoError(t, cache.Add("key-1", time.Now().Add(-time.Second)))
+		assert.NoError(t, cache.Add("key-1", time.Now().Add(time.Minute)))
+		assert.Equal(t, 1, len(cache.seen))
+		assert.Equal(t, 1, len(cache.expiries))
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		cache := NewMemoryReplayCache(2)
+		assert.NoError(t, cache.Add("key-1", time.Now().Add(time.Minute)))
+		assert.NoError(t, cache.Add("key-2", time.Now().Add(-time.Second)))
+
+		// Only expired keys make room
+		assert.NoError(t, cache.Add("key-3", time.Now().Add(time.Minute)))
+		assert.ErrorIs(t, cache.Add("key-4", time.Now().Add(time.Minute)), ErrReplayCacheFull)
+		assert.Equal(t, 2, len(cache.seen))
+		assert.ErrorIs(t, cache.Add("key-1", time.Now().Add(time.Minute)), ErrReplayedRequest)
+	})
+}